@@ -0,0 +1,254 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+	"github.com/lrstanley/discord-alertmanager/internal/models"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const oauth2TokenTimeout = 10 * time.Second
+
+// authError is an error encountered while authenticating a request (e.g. when
+// fetching a token), which isn't specific to any peer.
+type authError struct {
+	err error
+}
+
+func (e *authError) Error() string { return e.err.Error() }
+func (e *authError) Unwrap() error { return e.err }
+
+// bearerToken is a bearer token, either provided inline, or read from a file. The
+// file is re-read when it changes, so it can be rotated without restarting (e.g.
+// by a sidecar, or a mounted Kubernetes secret).
+type bearerToken struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+// Token returns the current token, re-reading the file if it has changed.
+func (t *bearerToken) Token() (string, error) {
+	if t.path == "" {
+		return t.token, nil
+	}
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read bearer token file: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && info.ModTime().Equal(t.modTime) && info.Size() == t.size {
+		return t.token, nil
+	}
+
+	b, err := os.ReadFile(t.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read bearer token file: %w", err)
+	}
+
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("bearer token file %q is empty", t.path)
+	}
+
+	t.token = token
+	t.modTime = info.ModTime()
+	t.size = info.Size()
+
+	return t.token, nil
+}
+
+// AuthenticateRequest implements runtime.ClientAuthInfoWriter.
+func (t *bearerToken) AuthenticateRequest(r runtime.ClientRequest, _ strfmt.Registry) error {
+	token, err := t.Token()
+	if err != nil {
+		return &authError{err: err}
+	}
+
+	return r.SetHeaderParam(runtime.HeaderAuthorization, "Bearer "+token)
+}
+
+// oauth2Token fetches tokens using the OAuth2 client-credentials flow. Tokens are
+// cached, and refreshed shortly before they expire.
+type oauth2Token struct {
+	source oauth2.TokenSource
+}
+
+func newOAuth2Token(config models.ConfigAlertmanager, transport http.RoundTripper) *oauth2Token {
+	cc := &clientcredentials.Config{
+		ClientID:     config.OAuth2ClientID,
+		ClientSecret: config.OAuth2ClientSecret,
+		TokenURL:     config.OAuth2TokenURL,
+		Scopes:       config.OAuth2Scopes,
+	}
+
+	if len(config.OAuth2Params) > 0 {
+		cc.EndpointParams = make(url.Values, len(config.OAuth2Params))
+		for k, v := range config.OAuth2Params {
+			cc.EndpointParams.Set(k, v)
+		}
+	}
+
+	// Use the same TLS configuration (e.g. private CAs) when fetching tokens.
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{
+		Transport: transport,
+		Timeout:   oauth2TokenTimeout,
+	})
+
+	return &oauth2Token{source: cc.TokenSource(ctx)}
+}
+
+// AuthenticateRequest implements runtime.ClientAuthInfoWriter.
+func (t *oauth2Token) AuthenticateRequest(r runtime.ClientRequest, _ strfmt.Registry) error {
+	token, err := t.source.Token()
+	if err != nil {
+		return &authError{err: fmt.Errorf("failed to fetch oauth2 token: %w", err)}
+	}
+
+	return r.SetHeaderParam(runtime.HeaderAuthorization, token.Type()+" "+token.AccessToken)
+}
+
+// newAuth returns how requests should be authenticated, if at all. Basic auth
+// credentials may come from the configuration, or from the instance URLs.
+func newAuth(
+	config models.ConfigAlertmanager,
+	username, password string,
+	transport http.RoundTripper,
+) (runtime.ClientAuthInfoWriter, error) {
+	var modes []string
+
+	if username != "" && password != "" {
+		modes = append(modes, "basic auth")
+	}
+
+	if config.BearerToken != "" || config.BearerTokenFile != "" {
+		modes = append(modes, "a bearer token")
+	}
+
+	if config.OAuth2TokenURL != "" {
+		modes = append(modes, "oauth2")
+	}
+
+	switch {
+	case config.BearerToken != "" && config.BearerTokenFile != "":
+		return nil, errors.New("only one of an alertmanager bearer token or bearer token file can be provided")
+	case len(modes) > 1:
+		return nil, fmt.Errorf("only one of alertmanager %s can be used", strings.Join(modes, " or "))
+	case config.OAuth2TokenURL != "":
+		if config.OAuth2ClientID == "" || config.OAuth2ClientSecret == "" {
+			return nil, errors.New("an alertmanager oauth2 client id and secret must be provided")
+		}
+
+		return newOAuth2Token(config, transport), nil
+	case config.BearerToken != "":
+		return &bearerToken{token: config.BearerToken}, nil
+	case config.BearerTokenFile != "":
+		token := &bearerToken{path: config.BearerTokenFile}
+
+		// Fail early if the file can't be read.
+		if _, err := token.Token(); err != nil {
+			return nil, err
+		}
+
+		return token, nil
+	case username != "" && password != "":
+		return httptransport.BasicAuth(username, password), nil
+	default:
+		return nil, nil
+	}
+}
+
+// headerTransport adds extra headers to every request.
+type headerTransport struct {
+	headers map[string]string
+	next    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// newTransport returns the transport used to talk to Alertmanager (and token
+// endpoints), configured with any TLS settings.
+func newTransport(config models.ConfigAlertmanager) (*http.Transport, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.TLSInsecureSkipVerify, //nolint:gosec
+	}
+
+	if config.TLSCAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		ca, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read alertmanager CA file: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in alertmanager CA file %q", config.TLSCAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return nil, errors.New("both an alertmanager client certificate and key must be provided")
+	}
+
+	if config.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load alertmanager client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// newHTTPClient returns the HTTP client used to talk to Alertmanager, which adds
+// any extra headers.
+func newHTTPClient(config models.ConfigAlertmanager, transport http.RoundTripper) *http.Client {
+	if len(config.Headers) > 0 {
+		transport = &headerTransport{headers: config.Headers, next: transport}
+	}
+
+	return &http.Client{Transport: transport}
+}
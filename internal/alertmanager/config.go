@@ -0,0 +1,329 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lrstanley/discord-alertmanager/internal/models"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the subset of the Alertmanager configuration the bot understands,
+// as parsed from the configuration reported by the status endpoint.
+type Config struct {
+	Route        *Route      `yaml:"route"`
+	Receivers    []*Receiver `yaml:"receivers"`
+	InhibitRules []yaml.Node `yaml:"inhibit_rules"`
+}
+
+// Route is a node in the Alertmanager routing tree.
+type Route struct {
+	Receiver string            `yaml:"receiver"`
+	GroupBy  []string          `yaml:"group_by"`
+	Match    map[string]string `yaml:"match"`
+	MatchRE  map[string]string `yaml:"match_re"`
+	Matchers []string          `yaml:"matchers"`
+	Continue bool              `yaml:"continue"`
+	Routes   []*Route          `yaml:"routes"`
+
+	parent   *Route
+	compiled Matchers
+}
+
+var reConfigMatcher = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*(.*?)\s*$`)
+
+// parseConfigMatchers parses an entry from the "matchers" list of a route, which
+// may contain multiple comma-separated matchers, optionally wrapped in braces, and
+// unlike filters, may have unquoted values (e.g. severity=critical).
+func parseConfigMatchers(input string) (matchers []*almodels.Matcher, err error) {
+	input = strings.TrimSpace(input)
+	input = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(input, "{"), "}"))
+
+	var parts []string
+	var quoted, escaped bool
+	var last int
+
+	for i, r := range input {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			parts = append(parts, input[last:i])
+			last = i + 1
+		}
+	}
+	parts = append(parts, input[last:])
+
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		match := reConfigMatcher.FindStringSubmatch(part)
+		if match == nil {
+			return nil, fmt.Errorf("invalid matcher %q", part)
+		}
+
+		value := match[3]
+		if strings.HasPrefix(value, `"`) {
+			value, err = strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid matcher %q: %w", part, err)
+			}
+		}
+
+		matchers = append(matchers, &almodels.Matcher{
+			Name:    models.Ptr(match[1]),
+			Value:   models.Ptr(value),
+			IsEqual: models.Ptr(strings.HasPrefix(match[2], "=")),
+			IsRegex: models.Ptr(strings.HasSuffix(match[2], "~")),
+		})
+	}
+
+	return matchers, nil
+}
+
+// init compiles the matchers of the route and its children, and inherits the
+// receiver and grouping from the parent, the same as Alertmanager.
+func (r *Route) init(parent *Route) error {
+	r.parent = parent
+
+	if parent != nil {
+		if r.Receiver == "" {
+			r.Receiver = parent.Receiver
+		}
+
+		if r.GroupBy == nil {
+			r.GroupBy = parent.GroupBy
+		}
+	}
+
+	var matchers []*almodels.Matcher
+
+	for _, name := range sortedKeys(r.Match) {
+		matchers = append(matchers, &almodels.Matcher{
+			Name:    models.Ptr(name),
+			Value:   models.Ptr(r.Match[name]),
+			IsEqual: models.Ptr(true),
+			IsRegex: models.Ptr(false),
+		})
+	}
+
+	for _, name := range sortedKeys(r.MatchRE) {
+		matchers = append(matchers, &almodels.Matcher{
+			Name:    models.Ptr(name),
+			Value:   models.Ptr(r.MatchRE[name]),
+			IsEqual: models.Ptr(true),
+			IsRegex: models.Ptr(true),
+		})
+	}
+
+	for _, input := range r.Matchers {
+		parsed, err := parseConfigMatchers(input)
+		if err != nil {
+			return err
+		}
+
+		matchers = append(matchers, parsed...)
+	}
+
+	var err error
+
+	r.compiled, err = CompileMatchers(matchers)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range r.Routes {
+		if err = child.init(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	return keys
+}
+
+// MatcherStrings returns the matchers of the route (e.g. `severity="critical"`).
+func (r *Route) MatcherStrings() []string {
+	out := make([]string, 0, len(r.compiled))
+	for _, m := range r.compiled {
+		out = append(out, m.String())
+	}
+
+	return out
+}
+
+// Matching returns the routes which would handle an alert with the provided labels,
+// using the same semantics as Alertmanager: child routes are evaluated in order,
+// stopping at the first match unless it has "continue" set. If no child routes
+// match, the route itself handles the alert.
+func (r *Route) Matching(labels map[string]string) []*Route {
+	if !r.compiled.Matches(labels) {
+		return nil
+	}
+
+	var matched []*Route
+
+	for _, child := range r.Routes {
+		childMatched := child.Matching(labels)
+		matched = append(matched, childMatched...)
+
+		if childMatched != nil && !child.Continue {
+			break
+		}
+	}
+
+	if len(matched) == 0 {
+		matched = append(matched, r)
+	}
+
+	return matched
+}
+
+// Path returns the routes from the root route to (and including) this route.
+func (r *Route) Path() []*Route {
+	var path []*Route
+	for route := r; route != nil; route = route.parent {
+		path = append([]*Route{route}, path...)
+	}
+
+	return path
+}
+
+// LabelsFor returns labels (in addition to the provided labels) which would route
+// an alert to the provided receiver, based on the matchers of the routes which use
+// it. False is returned if no such labels could be determined, e.g. because the
+// receiver is only reachable through complex regexes or negative matchers.
+func (r *Route) LabelsFor(receiver string, labels map[string]string) (map[string]string, bool) {
+	var candidates []*Route
+
+	var walk func(route *Route)
+	walk = func(route *Route) {
+		if route.Receiver == receiver {
+			candidates = append(candidates, route)
+		}
+
+		for _, child := range route.Routes {
+			walk(child)
+		}
+	}
+	walk(r)
+
+	for _, candidate := range candidates {
+		out := maps.Clone(labels)
+		if out == nil {
+			out = make(map[string]string)
+		}
+
+		for _, route := range candidate.Path() {
+			for _, m := range route.compiled {
+				if _, ok := out[m.Name]; ok {
+					continue
+				}
+
+				if value, ok := m.Example(); ok {
+					out[m.Name] = value
+				}
+			}
+		}
+
+		for _, route := range r.Matching(out) {
+			if route.Receiver == receiver {
+				return out, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// Receiver is a configured notification receiver.
+type Receiver struct {
+	Name string
+
+	// Integrations are the types of integrations configured for the receiver
+	// (e.g. "slack", "webhook").
+	Integrations []string
+}
+
+func (r *Receiver) UnmarshalYAML(value *yaml.Node) error {
+	var raw map[string]yaml.Node
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	if name, ok := raw["name"]; ok {
+		r.Name = name.Value
+	}
+
+	for key := range raw {
+		if integration, ok := strings.CutSuffix(key, "_configs"); ok {
+			r.Integrations = append(r.Integrations, integration)
+		}
+	}
+
+	slices.Sort(r.Integrations)
+
+	return nil
+}
+
+// ParseConfig parses the original Alertmanager configuration (as reported by the
+// status endpoint).
+func ParseConfig(original string) (*Config, error) {
+	config := &Config{}
+
+	if err := yaml.Unmarshal([]byte(original), config); err != nil {
+		return nil, fmt.Errorf("failed to parse alertmanager config: %w", err)
+	}
+
+	if config.Route == nil {
+		return nil, errors.New("alertmanager config has no root route")
+	}
+
+	if err := config.Route.init(nil); err != nil {
+		return nil, fmt.Errorf("failed to parse alertmanager routes: %w", err)
+	}
+
+	return config, nil
+}
+
+// Depth returns the depth of the routing tree, where a root route without any
+// child routes has a depth of 1.
+func (r *Route) Depth() (depth int) {
+	for _, child := range r.Routes {
+		if d := child.Depth(); d > depth {
+			depth = d
+		}
+	}
+
+	return depth + 1
+}
+
+// Count returns the number of routes in the routing tree, including the root.
+func (r *Route) Count() (count int) {
+	for _, child := range r.Routes {
+		count += child.Count()
+	}
+
+	return count + 1
+}
@@ -0,0 +1,263 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+const testConfig = `global:
+  resolve_timeout: 5m
+route:
+  receiver: default
+  group_by: [alertname]
+  routes:
+    - receiver: audit
+      matchers: ['severity=~"warning|critical"']
+      continue: true
+    - receiver: db
+      group_by: [alertname, instance]
+      matchers: ['{team="db", env!=dev}']
+      routes:
+        - receiver: db-pager
+          match: {severity: critical}
+        - match_re: {service: "postgres|mysql"}
+    - receiver: web
+      match_re: {service: "web|api"}
+receivers:
+  - name: default
+  - name: audit
+    webhook_configs: [{url: http://audit}]
+  - name: db
+    slack_configs: [{channel: '#db'}]
+  - name: db-pager
+    pagerduty_configs: [{routing_key: <secret>}]
+    webhook_configs: [{url: http://pager}]
+  - name: web
+inhibit_rules:
+  - source_matchers: [severity="critical"]
+    target_matchers: [severity="warning"]
+    equal: [alertname]
+`
+
+func receiversOf(routes []*Route) []string {
+	out := make([]string, 0, len(routes))
+	for _, r := range routes {
+		out = append(out, r.Receiver)
+	}
+
+	return out
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := config.Route.Count(); got != 6 {
+		t.Errorf("got %d routes, want 6", got)
+	}
+
+	if got := config.Route.Depth(); got != 3 {
+		t.Errorf("got depth %d, want 3", got)
+	}
+
+	if got := len(config.InhibitRules); got != 1 {
+		t.Errorf("got %d inhibit rules, want 1", got)
+	}
+
+	pager := config.Receivers[3]
+	if pager.Name != "db-pager" || !slices.Equal(pager.Integrations, []string{"pagerduty", "webhook"}) {
+		t.Errorf("got receiver %q with integrations %v", pager.Name, pager.Integrations)
+	}
+
+	db := config.Route.Routes[1]
+
+	if got := db.MatcherStrings(); !slices.Equal(got, []string{`team="db"`, `env!="dev"`}) {
+		t.Errorf("got matchers %v", got)
+	}
+
+	// Inherited from the parent, when not set.
+	if got := db.Routes[1].Receiver; got != "db" {
+		t.Errorf("got inherited receiver %q, want %q", got, "db")
+	}
+
+	if got := db.Routes[1].GroupBy; !slices.Equal(got, []string{"alertname", "instance"}) {
+		t.Errorf("got inherited group_by %v", got)
+	}
+
+	if got := config.Route.Routes[2].GroupBy; !slices.Equal(got, []string{"alertname"}) {
+		t.Errorf("got inherited group_by %v", got)
+	}
+
+	if got := receiversOf(db.Routes[0].Path()); !slices.Equal(got, []string{"default", "db", "db-pager"}) {
+		t.Errorf("got path %v", got)
+	}
+}
+
+func TestParseConfigInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no-route":        "receivers: [{name: default}]",
+		"invalid-matcher": "route: {receiver: default, matchers: ['team']}",
+		"invalid-regex":   "route: {receiver: default, match_re: {team: '(db'}}",
+		"invalid-yaml":    "route: [",
+	}
+
+	for name, input := range tests {
+		if _, err := ParseConfig(input); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestRouteMatching(t *testing.T) {
+	config, err := ParseConfig(testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []string
+	}{
+		{
+			name:   "no-match-uses-root",
+			labels: map[string]string{"alertname": "Down"},
+			want:   []string{"default"},
+		},
+		{
+			name:   "first-match",
+			labels: map[string]string{"service": "api"},
+			want:   []string{"web"},
+		},
+		{
+			name:   "legacy-match-re-anchored",
+			labels: map[string]string{"service": "webserver"},
+			want:   []string{"default"},
+		},
+		{
+			name:   "nested-match",
+			labels: map[string]string{"team": "db", "env": "prod", "severity": "critical"},
+			want:   []string{"audit", "db-pager"},
+		},
+		{
+			name:   "nested-no-child-match",
+			labels: map[string]string{"team": "db", "env": "prod"},
+			want:   []string{"db"},
+		},
+		{
+			name:   "nested-inherited-receiver",
+			labels: map[string]string{"team": "db", "service": "mysql"},
+			want:   []string{"db"},
+		},
+		{
+			name:   "negative-matcher",
+			labels: map[string]string{"team": "db", "env": "dev"},
+			want:   []string{"default"},
+		},
+		{
+			// A matching "continue" route counts as a match, so the root route isn't
+			// used, even if no later routes match.
+			name:   "negative-matcher-continue",
+			labels: map[string]string{"team": "db", "env": "dev", "severity": "critical"},
+			want:   []string{"audit"},
+		},
+		{
+			name:   "continue",
+			labels: map[string]string{"severity": "warning", "service": "web"},
+			want:   []string{"audit", "web"},
+		},
+		{
+			name:   "continue-only",
+			labels: map[string]string{"severity": "warning"},
+			want:   []string{"audit"},
+		},
+		{
+			name:   "first-match-stops",
+			labels: map[string]string{"team": "db", "service": "web"},
+			want:   []string{"db"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := receiversOf(config.Route.Matching(tt.labels)); !slices.Equal(got, tt.want) {
+				t.Fatalf("got receivers %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConfigMatchers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: `team="db"`, want: []string{`team="db"`}},
+		{input: `severity=critical`, want: []string{`severity="critical"`}},
+		{input: `{ team = "db" , env!=dev }`, want: []string{`team="db"`, `env!="dev"`}},
+		{input: `service=~"web|api", instance!~"10\\..*"`, want: []string{`service=~"web|api"`, `instance!~"10\\..*"`}},
+		{input: `msg="a, b"`, want: []string{`msg="a, b"`}},
+		{input: `msg="say \"hi\""`, want: []string{`msg="say \"hi\""`}},
+	}
+
+	for _, tt := range tests {
+		parsed, err := parseConfigMatchers(tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+
+		compiled, err := CompileMatchers(parsed)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+
+		got := make([]string, 0, len(compiled))
+		for _, m := range compiled {
+			got = append(got, m.String())
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{`team`, `=db`, `team="db`} {
+		if _, err := parseConfigMatchers(input); err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+}
+
+func TestRouteLabelsFor(t *testing.T) {
+	config, err := ParseConfig(testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels, ok := config.Route.LabelsFor("db-pager", map[string]string{"alertname": "Test"})
+	if !ok {
+		t.Fatal("expected labels for db-pager")
+	}
+
+	if labels["team"] != "db" || labels["severity"] != "critical" || labels["alertname"] != "Test" {
+		t.Errorf("got labels %v", labels)
+	}
+
+	labels, ok = config.Route.LabelsFor("web", nil)
+	if !ok || labels["service"] != "web" {
+		t.Errorf("got labels %v (ok: %v)", labels, ok)
+	}
+
+	if _, ok = config.Route.LabelsFor("unknown", nil); ok {
+		t.Error("expected no labels for unknown receiver")
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lrstanley/discord-alertmanager/internal/models"
+)
+
+// DefaultInstanceName is the name used for Alertmanager URLs configured without
+// a name.
+const DefaultInstanceName = "default"
+
+var reInstanceName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Instances are the configured Alertmanager instances (e.g. prod, staging, or
+// per-region Alertmanagers).
+type Instances struct {
+	list   []*Client
+	byName map[string]*Client
+}
+
+// ParseInstanceURL parses an Alertmanager URL in the format "[<name>=]<url>",
+// returning the default instance name if no name was provided.
+func ParseInstanceURL(input string) (name, rawURL string) {
+	input = strings.TrimSpace(input)
+
+	if i := strings.Index(input, "="); i != -1 && reInstanceName.MatchString(input[:i]) {
+		return input[:i], input[i+1:]
+	}
+
+	return DefaultInstanceName, input
+}
+
+// NewInstances creates a client for each configured Alertmanager instance. URLs
+// with the same name are treated as peers of the same HA cluster. The first
+// configured instance is the default.
+func NewInstances(config models.ConfigAlertmanager, debug bool) (*Instances, error) {
+	instances := &Instances{byName: make(map[string]*Client)}
+
+	var names []string
+	peers := make(map[string][]string)
+
+	for _, input := range config.URLs {
+		if strings.TrimSpace(input) == "" {
+			continue
+		}
+
+		name, rawURL := ParseInstanceURL(input)
+
+		if _, ok := peers[name]; !ok {
+			names = append(names, name)
+		}
+
+		peers[name] = append(peers[name], rawURL)
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no alertmanager urls configured")
+	}
+
+	for _, name := range names {
+		client, err := NewClient(name, peers[name], config, debug)
+		if err != nil {
+			return nil, fmt.Errorf("alertmanager instance %q: %w", name, err)
+		}
+
+		instances.list = append(instances.list, client)
+		instances.byName[name] = client
+	}
+
+	return instances, nil
+}
+
+// Get returns the instance with the provided name.
+func (i *Instances) Get(name string) (*Client, bool) {
+	client, ok := i.byName[name]
+	return client, ok
+}
+
+// Default returns the default (first configured) instance.
+func (i *Instances) Default() *Client {
+	return i.list[0]
+}
+
+// List returns all instances, in the order they were configured.
+func (i *Instances) List() []*Client {
+	return i.list
+}
+
+// Multiple returns true if more than one instance is configured.
+func (i *Instances) Multiple() bool {
+	return len(i.list) > 1
+}
+
+// ForURL returns the instance which the provided URL (e.g. a silence URL) belongs
+// to.
+func (i *Instances) ForURL(uri string) (*Client, bool) {
+	for _, client := range i.list {
+		if client.HasURL(uri) {
+			return client, true
+		}
+	}
+
+	return nil, false
+}
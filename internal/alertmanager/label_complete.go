@@ -0,0 +1,166 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/participle/v2/lexer"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+)
+
+var unescapeQuoted = strings.NewReplacer(`\"`, `"`, `\'`, `'`, `\\`, `\`)
+
+// PartialLabels is the result of parsing a partially typed list of labels, where
+// the cursor is assumed to be at the end of the input.
+type PartialLabels struct {
+	// Matchers are the fully entered matchers, before the one at the cursor.
+	Matchers []*almodels.Matcher
+
+	// Prefix is the input up to (but excluding) the token at the cursor.
+	Prefix string
+
+	// IsValue is true if the cursor is on a label value, otherwise it's on a
+	// label name.
+	IsValue bool
+
+	// Name and Equality are the label name and equality operator of the matcher
+	// at the cursor, if the cursor is on a value.
+	Name     string
+	Equality string
+
+	// Partial is the (unquoted) name or value typed so far at the cursor.
+	Partial string
+}
+
+// lexPartial tokenizes the input, closing any unterminated quoted string, or
+// incomplete "!" operator.
+func lexPartial(input string) (tokens []lexer.Token, err error) {
+	for _, suffix := range []string{"", `"`, `'`, "="} {
+		var lx lexer.Lexer
+
+		lx, err = lex.Lex("", strings.NewReader(input+suffix))
+		if err != nil {
+			return nil, err
+		}
+
+		tokens, err = lexer.ConsumeAll(lx)
+		if err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Strip the EOF token.
+	return tokens[:len(tokens)-1], nil
+}
+
+// ParsePartialLabels parses a partially typed list of labels (e.g. while the user
+// is still typing), using the same grammar as ParseLabels, and determines whether
+// the cursor (the end of the input) is on a label name or value.
+func ParsePartialLabels(input string) (*PartialLabels, error) {
+	// Allow the labels to be wrapped in braces, like Prometheus selectors. Token
+	// offsets are relative to start.
+	var start int
+	if trimmed := strings.TrimLeft(input, " \t\n\r"); strings.HasPrefix(trimmed, "{") {
+		start = len(input) - len(trimmed) + 1
+	}
+
+	tokens, err := lexPartial(input[start:])
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := lex.Symbols()
+
+	// Positions of non-separator tokens, which must be in the form of repeated
+	// <ident> <equality> <value>.
+	var entry []lexer.Token
+	entryStart := len(input)
+
+	for _, token := range tokens {
+		if token.Type == symbols["Separator"] {
+			continue
+		}
+
+		if len(entry) == 3 { //nolint:gomnd
+			entry = nil
+		}
+
+		if len(entry) == 0 {
+			entryStart = start + token.Pos.Offset
+		}
+
+		entry = append(entry, token)
+	}
+
+	trailingSeparator := len(tokens) > 0 && tokens[len(tokens)-1].Type == symbols["Separator"]
+
+	p := &PartialLabels{}
+
+	switch {
+	case len(entry) == 0 || (len(entry) == 3 && trailingSeparator): //nolint:gomnd
+		// Starting a new entry.
+		p.Prefix = input
+		entryStart = len(input)
+	case trailingSeparator:
+		return nil, errors.New("incomplete label matcher")
+	case len(entry) == 1:
+		if entry[0].Type != symbols["Ident"] {
+			return nil, errors.New("expected label name")
+		}
+
+		p.Prefix = input[:start+entry[0].Pos.Offset]
+		p.Partial = entry[0].Value
+	case len(entry) == 2: //nolint:gomnd
+		if entry[1].Type != symbols["Equality"] {
+			return nil, errors.New("expected equality operator")
+		}
+
+		// The operator may have been completed when lexing (e.g. "!" to "!=").
+		p.Prefix = input[:start+entry[1].Pos.Offset] + entry[1].Value
+		p.IsValue = true
+		p.Name = entry[0].Value
+		p.Equality = entry[1].Value
+	default:
+		value := entry[2]
+
+		p.Prefix = input[:start+value.Pos.Offset]
+		p.IsValue = true
+		p.Name = entry[0].Value
+		p.Equality = entry[1].Value
+		p.Partial = value.Value
+
+		if value.Type == symbols["StringSingle"] || value.Type == symbols["StringDouble"] {
+			// Values with an unterminated quote had one added when lexing.
+			p.Partial = unescapeQuoted.Replace(value.Value[1 : len(value.Value)-1])
+		}
+	}
+
+	if strings.TrimSpace(input[start:entryStart]) != "" {
+		p.Matchers, err = ParseLabels(input[start:entryStart], true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Complete returns the input with the name or value at the cursor replaced with
+// the provided text. Names are followed by an equality operator, ready for the
+// value to be typed.
+func (p *PartialLabels) Complete(text string) string {
+	if p.IsValue {
+		return p.Prefix + strconv.Quote(text)
+	}
+
+	return p.Prefix + text + "="
+}
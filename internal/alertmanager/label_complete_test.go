@@ -0,0 +1,125 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestParsePartialLabels(t *testing.T) {
+	tests := []struct {
+		input    string
+		isValue  bool
+		name     string
+		equality string
+		partial  string
+		matchers []string
+		complete string
+	}{
+		{input: "", complete: "x="},
+		{input: "{", complete: "{x="},
+		{input: "{foo", partial: "foo", complete: "{x="},
+		{input: "foo", partial: "foo", complete: "x="},
+		{input: "{foo=", isValue: true, name: "foo", equality: "=", complete: `{foo="x"`},
+		{input: `{foo="ba`, isValue: true, name: "foo", equality: "=", partial: "ba", complete: `{foo="x"`},
+		{input: `{foo="a\"b`, isValue: true, name: "foo", equality: "=", partial: `a"b`, complete: `{foo="x"`},
+		{input: `foo='ba`, isValue: true, name: "foo", equality: "=", partial: "ba", complete: `foo="x"`},
+		{input: `foo=ba`, isValue: true, name: "foo", equality: "=", partial: "ba", complete: `foo="x"`},
+		{
+			input:    `{foo="bar",`,
+			matchers: []string{`foo="bar"`},
+			complete: `{foo="bar",x=`,
+		},
+		{
+			input:    `foo="bar" `,
+			matchers: []string{`foo="bar"`},
+			complete: `foo="bar" x=`,
+		},
+		{
+			input:    `foo=~"x", ba`,
+			partial:  "ba",
+			matchers: []string{`foo=~"x"`},
+			complete: `foo=~"x", x=`,
+		},
+		{
+			input:    `foo=~"x", bar!`,
+			isValue:  true,
+			name:     "bar",
+			equality: "!=",
+			matchers: []string{`foo=~"x"`},
+			complete: `foo=~"x", bar!="x"`,
+		},
+		{
+			input:    `foo=~"x", bar!~"y`,
+			isValue:  true,
+			name:     "bar",
+			equality: "!~",
+			partial:  "y",
+			matchers: []string{`foo=~"x"`},
+			complete: `foo=~"x", bar!~"x"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p, err := ParsePartialLabels(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p.IsValue != tt.isValue || p.Name != tt.name || p.Equality != tt.equality || p.Partial != tt.partial {
+				t.Errorf(
+					"got is_value=%v name=%q equality=%q partial=%q, want is_value=%v name=%q equality=%q partial=%q",
+					p.IsValue, p.Name, p.Equality, p.Partial, tt.isValue, tt.name, tt.equality, tt.partial,
+				)
+			}
+
+			if got := MatcherToString(p.Matchers, false); !slices.Equal(got, tt.matchers) {
+				t.Errorf("got matchers %v, want %v", got, tt.matchers)
+			}
+
+			if got := p.Complete("x"); got != tt.complete {
+				t.Errorf("got completion %q, want %q", got, tt.complete)
+			}
+		})
+	}
+}
+
+func TestParsePartialLabelsInvalid(t *testing.T) {
+	for _, input := range []string{
+		`foo="bar",,=`,
+		`foo= ,`,
+		`"foo"`,
+		`foo bar`,
+		`foo="x", =`,
+		`foo="x"}`,
+	} {
+		if p, err := ParsePartialLabels(input); err == nil {
+			t.Errorf("%q: expected error, got %+v", input, p)
+		}
+	}
+}
+
+func TestParseLabelsBraces(t *testing.T) {
+	for _, input := range []string{`{foo="bar", baz!~"x"}`, ` { foo="bar" baz!~"x" } `, ` foo="bar" baz!~"x" `} {
+		matchers, err := ParseLabels(input, false)
+		if err != nil {
+			t.Errorf("%q: %v", input, err)
+			continue
+		}
+
+		if got := MatcherToString(matchers, false); !slices.Equal(got, []string{`foo="bar"`, `baz!~"x"`}) {
+			t.Errorf("%q: got matchers %v", input, got)
+		}
+	}
+
+	for _, input := range []string{`{foo="bar", baz!~"x"`, `foo="bar", baz!~"x"}`} {
+		if _, err := ParseLabels(input, false); err == nil {
+			t.Errorf("%q: expected error for unbalanced braces", input)
+		}
+	}
+}
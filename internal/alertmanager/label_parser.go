@@ -13,14 +13,15 @@ import (
 	"github.com/alecthomas/participle/v2/lexer"
 	"github.com/lrstanley/discord-alertmanager/internal/models"
 	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 )
 
 var (
 	lex = lexer.MustSimple([]lexer.SimpleRule{
 		{Name: "Ident", Pattern: `[a-zA-Z_][a-zA-Z0-9_]*`},
-		{Name: "StringSingle", Pattern: `'(?:\\.|[^'])*'`},
-		{Name: "StringDouble", Pattern: `"(?:\\.|[^"])*"`},
+		{Name: "StringSingle", Pattern: `'(?:\\.|[^'\\])*'`},
+		{Name: "StringDouble", Pattern: `"(?:\\.|[^"\\])*"`},
 		{Name: "Equality", Pattern: `(!=|=~|!~|=)`},
 		{Name: "Separator", Pattern: `[ \t\n\r,]+`},
 	})
@@ -47,6 +48,7 @@ var (
 //	foo=~"bar" foo!~"bar"
 //	foo!="^foo\"test\"bar[^baz]+$"
 //	foo=bar123
+//	{foo="bar", bar!="baz"}
 
 type ParseResults struct {
 	Entries []*LabelEntry `parser:"( @@* | @@ ( ',' @@ )+ )"`
@@ -76,6 +78,18 @@ func (l *LabelEntry) String() string {
 func ParseLabels(input string, allowDuplicates bool) (matchers []*almodels.Matcher, err error) {
 	var ast *ParseResults
 
+	// Allow the labels to be wrapped in braces, like Prometheus selectors.
+	input = strings.TrimSpace(input)
+	opening, closing := strings.HasPrefix(input, "{"), strings.HasSuffix(input, "}")
+
+	if opening != closing {
+		return nil, errors.New("unbalanced braces around label matchers")
+	}
+
+	if opening {
+		input = input[1 : len(input)-1]
+	}
+
 	ast, err = parser.ParseString("", input)
 	if err != nil {
 		return nil, err
@@ -174,3 +188,21 @@ func MatcherToString(matchers []*almodels.Matcher, pad bool) (out []string) {
 
 	return out
 }
+
+// LabelsToMatchers converts a label set into a list of equality matchers, sorted
+// by label name.
+func LabelsToMatchers(labels map[string]string) (matchers []*almodels.Matcher) {
+	names := maps.Keys(labels)
+	slices.Sort(names)
+
+	for _, name := range names {
+		matchers = append(matchers, &almodels.Matcher{
+			Name:    models.Ptr(name),
+			Value:   models.Ptr(labels[name]),
+			IsEqual: models.Ptr(true),
+			IsRegex: models.Ptr(false),
+		})
+	}
+
+	return matchers
+}
@@ -0,0 +1,257 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"unicode"
+
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+)
+
+// MatchType is the type of comparison a matcher does against a label value.
+type MatchType int
+
+const (
+	MatchEqual     MatchType = iota // =
+	MatchNotEqual                   // !=
+	MatchRegexp                     // =~
+	MatchNotRegexp                  // !~
+)
+
+func (t MatchType) String() string {
+	switch t {
+	case MatchEqual:
+		return "="
+	case MatchNotEqual:
+		return "!="
+	case MatchRegexp:
+		return "=~"
+	case MatchNotRegexp:
+		return "!~"
+	default:
+		return "?"
+	}
+}
+
+// Matcher is a compiled version of an Alertmanager matcher, which can be evaluated
+// against label sets locally, using the same semantics as Alertmanager.
+type Matcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+
+	re *regexp.Regexp
+}
+
+// NewMatcher compiles an Alertmanager matcher. Regex matchers are fully anchored,
+// the same as Alertmanager (i.e. "foo.*" is evaluated as "^(?:foo.*)$").
+func NewMatcher(m *almodels.Matcher) (*Matcher, error) {
+	if m == nil || m.Name == nil || m.Value == nil {
+		return nil, errors.New("matcher is missing a name or value")
+	}
+
+	isEqual := m.IsEqual == nil || *m.IsEqual // Alertmanager defaults to true.
+	isRegex := m.IsRegex != nil && *m.IsRegex
+
+	matcher := &Matcher{Name: *m.Name, Value: *m.Value}
+
+	switch {
+	case isEqual && !isRegex:
+		matcher.Type = MatchEqual
+	case !isEqual && !isRegex:
+		matcher.Type = MatchNotEqual
+	case isEqual && isRegex:
+		matcher.Type = MatchRegexp
+	default:
+		matcher.Type = MatchNotRegexp
+	}
+
+	if isRegex {
+		re, err := regexp.Compile("^(?:" + matcher.Value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex for label %q: %w", matcher.Name, err)
+		}
+
+		matcher.re = re
+	}
+
+	return matcher, nil
+}
+
+// Matches returns true if the provided label value satisfies the matcher.
+func (m *Matcher) Matches(value string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	default:
+		return false
+	}
+}
+
+func (m *Matcher) String() string {
+	return m.Name + m.Type.String() + strconv.Quote(m.Value)
+}
+
+// Example returns a label value which satisfies the matcher, if one can easily
+// be determined (e.g. for equality matchers, or regexes made up of literals,
+// alternations and character classes, like "web|api" or "db-[0-9]").
+func (m *Matcher) Example() (string, bool) {
+	switch m.Type {
+	case MatchEqual:
+		return m.Value, true
+	case MatchRegexp:
+		re, err := syntax.Parse(m.Value, syntax.Perl)
+		if err != nil {
+			return "", false
+		}
+
+		return regexpExample(re.Simplify())
+	default:
+		return "", false
+	}
+}
+
+func regexpExample(re *syntax.Regexp) (string, bool) {
+	switch re.Op { //nolint:exhaustive
+	case syntax.OpEmptyMatch:
+		return "", true
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return "", false
+		}
+
+		return string(re.Rune), true
+	case syntax.OpCharClass:
+		// Rune holds pairs of ranges. Alternations of single characters, or with a
+		// common prefix, are parsed into classes too (e.g. "api|app" as "ap[ip]").
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if unicode.IsPrint(r) {
+					return string(r), true
+				}
+			}
+		}
+
+		return "", false
+	case syntax.OpCapture, syntax.OpAlternate:
+		return regexpExample(re.Sub[0])
+	case syntax.OpConcat:
+		var out string
+		for _, sub := range re.Sub {
+			v, ok := regexpExample(sub)
+			if !ok {
+				return "", false
+			}
+
+			out += v
+		}
+
+		return out, true
+	default:
+		return "", false
+	}
+}
+
+// Matchers is a list of compiled matchers, all of which must match for a label
+// set to be matched.
+type Matchers []*Matcher
+
+// CompileMatchers compiles a list of Alertmanager matchers, returning an error if
+// any of them are invalid (e.g. an invalid regex).
+func CompileMatchers(matchers []*almodels.Matcher) (Matchers, error) {
+	out := make(Matchers, 0, len(matchers))
+
+	for _, m := range matchers {
+		compiled, err := NewMatcher(m)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, compiled)
+	}
+
+	return out, nil
+}
+
+// Matches returns true if all matchers match the provided label set. Labels which
+// don't exist in the label set are treated as empty, so for example, foo!="bar"
+// matches label sets without a "foo" label.
+func (ms Matchers) Matches(labels map[string]string) bool {
+	for _, m := range ms {
+		if !m.Matches(labels[m.Name]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatchLabels compiles the provided matchers, and evaluates them against the label
+// set. When evaluating against many label sets, use CompileMatchers instead.
+func MatchLabels(matchers []*almodels.Matcher, labels map[string]string) (bool, error) {
+	compiled, err := CompileMatchers(matchers)
+	if err != nil {
+		return false, err
+	}
+
+	return compiled.Matches(labels), nil
+}
+
+// IsMatchAll returns true if the matcher is a regex which matches any value (e.g.
+// foo=~".*" or foo=~".+"), and as such, doesn't meaningfully restrict which alerts
+// are matched.
+func (m *Matcher) IsMatchAll() bool {
+	if m.Type != MatchRegexp {
+		return false
+	}
+
+	re, err := syntax.Parse(m.Value, syntax.Perl)
+	if err != nil {
+		return false
+	}
+
+	return isMatchAllRegexp(re.Simplify())
+}
+
+func isMatchAllRegexp(re *syntax.Regexp) bool {
+	switch re.Op { //nolint:exhaustive
+	case syntax.OpCapture:
+		return isMatchAllRegexp(re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus:
+		return re.Sub[0].Op == syntax.OpAnyChar || re.Sub[0].Op == syntax.OpAnyCharNotNL
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if isMatchAllRegexp(sub) {
+				return true
+			}
+		}
+	case syntax.OpConcat:
+		// Allow redundant anchors, e.g. "^.*$".
+		var rest []*syntax.Regexp
+		for _, sub := range re.Sub {
+			switch sub.Op { //nolint:exhaustive
+			case syntax.OpBeginLine, syntax.OpBeginText, syntax.OpEndLine, syntax.OpEndText:
+				continue
+			}
+
+			rest = append(rest, sub)
+		}
+
+		return len(rest) == 1 && isMatchAllRegexp(rest[0])
+	}
+
+	return false
+}
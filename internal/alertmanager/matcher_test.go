@@ -0,0 +1,267 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"testing"
+
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+)
+
+func newMatcher(name, value string, isEqual, isRegex bool) *almodels.Matcher {
+	return &almodels.Matcher{
+		Name:    &name,
+		Value:   &value,
+		IsEqual: &isEqual,
+		IsRegex: &isRegex,
+	}
+}
+
+func testMatcher(t *testing.T, name, value string, isEqual, isRegex bool) *Matcher {
+	t.Helper()
+
+	m, err := NewMatcher(newMatcher(name, value, isEqual, isRegex))
+	if err != nil {
+		t.Fatalf("failed to compile matcher %s: %v", name, err)
+	}
+
+	return m
+}
+
+func TestNewMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		isEqual bool
+		isRegex bool
+		want    MatchType
+	}{
+		{name: "equal", isEqual: true, isRegex: false, want: MatchEqual},
+		{name: "not-equal", isEqual: false, isRegex: false, want: MatchNotEqual},
+		{name: "regexp", isEqual: true, isRegex: true, want: MatchRegexp},
+		{name: "not-regexp", isEqual: false, isRegex: true, want: MatchNotRegexp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testMatcher(t, "foo", "bar", tt.isEqual, tt.isRegex)
+			if m.Type != tt.want {
+				t.Fatalf("got type %s, want %s", m.Type, tt.want)
+			}
+		})
+	}
+
+	t.Run("default-is-equal", func(t *testing.T) {
+		name, value := "foo", "bar"
+
+		m, err := NewMatcher(&almodels.Matcher{Name: &name, Value: &value})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if m.Type != MatchEqual {
+			t.Fatalf("got type %s, want %s", m.Type, MatchEqual)
+		}
+	})
+
+	t.Run("missing-value", func(t *testing.T) {
+		name := "foo"
+
+		if _, err := NewMatcher(&almodels.Matcher{Name: &name}); err == nil {
+			t.Fatal("expected error for matcher without a value")
+		}
+	})
+
+	t.Run("invalid-regex", func(t *testing.T) {
+		name, value, isRegex := "foo", "(bar", true
+
+		if _, err := NewMatcher(&almodels.Matcher{Name: &name, Value: &value, IsRegex: &isRegex}); err == nil {
+			t.Fatal("expected error for invalid regex")
+		}
+	})
+}
+
+func TestMatcherMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		isEqual bool
+		isRegex bool
+		input   string
+		want    bool
+	}{
+		{name: "equal", value: "bar", isEqual: true, input: "bar", want: true},
+		{name: "equal-mismatch", value: "bar", isEqual: true, input: "baz", want: false},
+		{name: "not-equal", value: "bar", input: "baz", want: true},
+		{name: "not-equal-mismatch", value: "bar", input: "bar", want: false},
+		{name: "regexp", value: "ba[rz]", isEqual: true, isRegex: true, input: "baz", want: true},
+		{name: "regexp-mismatch", value: "ba[rz]", isEqual: true, isRegex: true, input: "bat", want: false},
+		{name: "regexp-anchored-prefix", value: "bar", isEqual: true, isRegex: true, input: "foobar", want: false},
+		{name: "regexp-anchored-suffix", value: "bar", isEqual: true, isRegex: true, input: "barfoo", want: false},
+		{name: "regexp-anchored-alternation", value: "a|b", isEqual: true, isRegex: true, input: "ab", want: false},
+		{name: "not-regexp", value: "ba[rz]", isRegex: true, input: "bat", want: true},
+		{name: "not-regexp-mismatch", value: "ba[rz]", isRegex: true, input: "bar", want: false},
+		{name: "not-regexp-anchored", value: "bar", isRegex: true, input: "foobar", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testMatcher(t, "foo", tt.value, tt.isEqual, tt.isRegex)
+			if got := m.Matches(tt.input); got != tt.want {
+				t.Fatalf("%s matching %q: got %v, want %v", m, tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchersMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchers []*almodels.Matcher
+		labels   map[string]string
+		want     bool
+	}{
+		{
+			name:     "all-match",
+			matchers: []*almodels.Matcher{newMatcher("alertname", "Down", true, false), newMatcher("env", "prod|staging", true, true)},
+			labels:   map[string]string{"alertname": "Down", "env": "prod"},
+			want:     true,
+		},
+		{
+			name:     "one-mismatch",
+			matchers: []*almodels.Matcher{newMatcher("alertname", "Down", true, false), newMatcher("env", "prod|staging", true, true)},
+			labels:   map[string]string{"alertname": "Down", "env": "dev"},
+			want:     false,
+		},
+		{
+			name:     "no-matchers",
+			matchers: nil,
+			labels:   map[string]string{"alertname": "Down"},
+			want:     true,
+		},
+		{
+			name:     "missing-label-equal",
+			matchers: []*almodels.Matcher{newMatcher("foo", "bar", true, false)},
+			labels:   map[string]string{"alertname": "Down"},
+			want:     false,
+		},
+		{
+			name:     "missing-label-equal-empty",
+			matchers: []*almodels.Matcher{newMatcher("foo", "", true, false)},
+			labels:   map[string]string{"alertname": "Down"},
+			want:     true,
+		},
+		{
+			name:     "missing-label-not-equal",
+			matchers: []*almodels.Matcher{newMatcher("foo", "bar", false, false)},
+			labels:   map[string]string{"alertname": "Down"},
+			want:     true,
+		},
+		{
+			name:     "missing-label-regexp",
+			matchers: []*almodels.Matcher{newMatcher("foo", "bar.*", true, true)},
+			labels:   map[string]string{"alertname": "Down"},
+			want:     false,
+		},
+		{
+			name:     "missing-label-regexp-match-all",
+			matchers: []*almodels.Matcher{newMatcher("foo", ".*", true, true)},
+			labels:   map[string]string{"alertname": "Down"},
+			want:     true,
+		},
+		{
+			name:     "missing-label-regexp-non-empty",
+			matchers: []*almodels.Matcher{newMatcher("foo", ".+", true, true)},
+			labels:   map[string]string{"alertname": "Down"},
+			want:     false,
+		},
+		{
+			name:     "missing-label-not-regexp",
+			matchers: []*almodels.Matcher{newMatcher("foo", "bar", false, true)},
+			labels:   map[string]string{"alertname": "Down"},
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MatchLabels(tt.matchers, tt.labels)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherIsMatchAll(t *testing.T) {
+	tests := []struct {
+		value   string
+		isEqual bool
+		isRegex bool
+		want    bool
+	}{
+		{value: ".*", isEqual: true, isRegex: true, want: true},
+		{value: ".+", isEqual: true, isRegex: true, want: true},
+		{value: "(?:.*)", isEqual: true, isRegex: true, want: true},
+		{value: "(.*)", isEqual: true, isRegex: true, want: true},
+		{value: "^.*$", isEqual: true, isRegex: true, want: true},
+		{value: ".*|foo", isEqual: true, isRegex: true, want: true},
+		{value: "foo|.*", isEqual: true, isRegex: true, want: true},
+		{value: "foo.*", isEqual: true, isRegex: true, want: false},
+		{value: "foo|bar", isEqual: true, isRegex: true, want: false},
+		{value: ".", isEqual: true, isRegex: true, want: false},
+		{value: ".*", isEqual: false, isRegex: true, want: false},
+		{value: ".*", isEqual: true, isRegex: false, want: false},
+	}
+
+	for _, tt := range tests {
+		m := testMatcher(t, "foo", tt.value, tt.isEqual, tt.isRegex)
+		if got := m.IsMatchAll(); got != tt.want {
+			t.Errorf("%s: got %v, want %v", m, got, tt.want)
+		}
+	}
+}
+
+func TestMatcherExample(t *testing.T) {
+	tests := []struct {
+		value   string
+		isEqual bool
+		isRegex bool
+		want    string
+		ok      bool
+	}{
+		{value: "web", isEqual: true, want: "web", ok: true},
+		{value: "", isEqual: true, want: "", ok: true},
+		{value: "web", isEqual: true, isRegex: true, want: "web", ok: true},
+		{value: "web|api", isEqual: true, isRegex: true, want: "web", ok: true},
+		{value: "api|app", isEqual: true, isRegex: true, want: "api", ok: true},
+		{value: "a|b", isEqual: true, isRegex: true, want: "a", ok: true},
+		{value: "db-[0-9]", isEqual: true, isRegex: true, want: "db-0", ok: true},
+		{value: "[^a]", isEqual: true, isRegex: true, want: " ", ok: true},
+		{value: "(prod|staging)-eu", isEqual: true, isRegex: true, want: "prod-eu", ok: true},
+		{value: "(?:web)", isEqual: true, isRegex: true, want: "web", ok: true},
+		{value: "(?i)web", isEqual: true, isRegex: true, ok: false},
+		{value: "web.*", isEqual: true, isRegex: true, ok: false},
+		{value: "web", isRegex: false, ok: false},
+		{value: "web", isRegex: true, ok: false},
+	}
+
+	for _, tt := range tests {
+		m := testMatcher(t, "foo", tt.value, tt.isEqual, tt.isRegex)
+
+		got, ok := m.Example()
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: got %q (ok: %v), want %q (ok: %v)", m, got, ok, tt.want, tt.ok)
+			continue
+		}
+
+		if ok && !m.Matches(got) {
+			t.Errorf("%s: example %q doesn't match", m, got)
+		}
+	}
+}
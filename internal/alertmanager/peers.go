@@ -0,0 +1,274 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/go-openapi/runtime"
+	httptransport "github.com/go-openapi/runtime/client"
+	alclient "github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/client/general"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+// peer is a single Alertmanager, which may be part of an HA cluster.
+type peer struct {
+	url     string
+	runtime *httptransport.Runtime
+	api     *alclient.AlertmanagerAPI
+
+	mu      sync.RWMutex
+	healthy bool
+	err     error
+	checked time.Time
+	status  *almodels.AlertmanagerStatus
+}
+
+// PeerState is a snapshot of the health of a peer.
+type PeerState struct {
+	URL     string
+	Healthy bool
+	// Error is the last error encountered when talking to the peer, if unhealthy.
+	Error error
+	// Checked is when the peer was last health-checked, zero if never.
+	Checked time.Time
+	// Status is the status reported by the peer during the last successful
+	// health-check, including its view of the cluster.
+	Status *almodels.AlertmanagerStatus
+}
+
+func newPeer(uri *url.URL, client *http.Client) *peer {
+	basePath := uri.Path
+	if basePath == "" || basePath == "/" {
+		basePath = alclient.DefaultBasePath
+	}
+
+	p := &peer{
+		url:     uri.String(),
+		runtime: httptransport.NewWithClient(uri.Host, basePath, []string{uri.Scheme}, client),
+		// Assume peers are healthy until proven otherwise.
+		healthy: true,
+	}
+	p.api = alclient.New(p.runtime, nil)
+
+	return p
+}
+
+func (p *peer) state() PeerState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return PeerState{
+		URL:     p.url,
+		Healthy: p.healthy,
+		Error:   p.err,
+		Checked: p.checked,
+		Status:  p.status,
+	}
+}
+
+func (p *peer) isHealthy() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.healthy
+}
+
+// setHealth updates the peer health, returning true if it changed.
+func (p *peer) setHealth(err error) (changed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed = p.healthy != (err == nil)
+	p.healthy = err == nil
+	p.err = err
+
+	return changed
+}
+
+// shouldFailover returns true if the request should be retried against another
+// peer, which is the case for connection errors and 5xx responses.
+func shouldFailover(ctx context.Context, err error) bool {
+	if err == nil || (ctx != nil && ctx.Err() != nil) {
+		return false
+	}
+
+	// Authentication errors (e.g. the token endpoint being down) aren't specific
+	// to a peer.
+	var authErr *authError
+	if errors.As(err, &authErr) {
+		return false
+	}
+
+	// Generated response types for documented status codes.
+	var serverErr interface{ IsServerError() bool }
+	if errors.As(err, &serverErr) {
+		return serverErr.IsServerError()
+	}
+
+	// Undocumented status codes.
+	var apiErr *runtime.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= 500
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// isUnsent returns true if the request failed before anything was sent to the
+// peer (e.g. the connection was refused), so it can't have been applied.
+func isUnsent(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.As(err, &dnsErr)
+}
+
+// orderedPeers returns healthy peers first, in the order they were configured,
+// followed by unhealthy peers, as a last resort.
+func (c *Client) orderedPeers() []*peer {
+	ordered := make([]*peer, 0, len(c.peers))
+
+	for _, p := range c.peers {
+		if p.isHealthy() {
+			ordered = append(ordered, p)
+		}
+	}
+
+	for _, p := range c.peers {
+		if !p.isHealthy() {
+			ordered = append(ordered, p)
+		}
+	}
+
+	return ordered
+}
+
+// submitPeers sends the request to a healthy peer, failing over to the next peer
+// on connection errors or 5xx responses. Non-idempotent requests only fail over
+// if the peer never received them, as they may have otherwise been applied.
+func (c *Client) submitPeers(op *runtime.ClientOperation) (result any, err error) {
+	for _, p := range c.orderedPeers() {
+		result, err = p.runtime.Submit(op)
+
+		if !shouldFailover(op.Context, err) {
+			p.setHealth(nil)
+			return result, err
+		}
+
+		p.setHealth(err)
+
+		if !isIdempotent(op.Method) && !isUnsent(err) {
+			return result, err
+		}
+	}
+
+	return result, err
+}
+
+// Peers returns the state of each peer, in the order they were configured.
+func (c *Client) Peers() []PeerState {
+	states := make([]PeerState, 0, len(c.peers))
+	for _, p := range c.peers {
+		states = append(states, p.state())
+	}
+
+	return states
+}
+
+// HealthCheck fetches the status of each peer, updating their health.
+func (c *Client) HealthCheck(ctx context.Context) {
+	logger := log.FromContext(ctx).WithFields(log.Fields{
+		"src":      "alertmanager",
+		"instance": c.Name,
+	})
+
+	var wg sync.WaitGroup
+
+	for _, p := range c.peers {
+		wg.Add(1)
+
+		go func(p *peer) {
+			defer wg.Done()
+
+			params := &general.GetStatusParams{}
+			params.SetContext(ctx)
+			params.SetTimeout(healthCheckTimeout)
+
+			resp, err := p.api.General.GetStatus(params, c.HandleAuth)
+			if ctx.Err() != nil {
+				return
+			}
+
+			p.mu.Lock()
+			p.checked = time.Now()
+			if err == nil {
+				p.status = resp.Payload
+			}
+			p.mu.Unlock()
+
+			// A healthy peer means requests can be sent again, without waiting
+			// for the circuit breaker cooldown.
+			if err == nil && c.breaker.success() {
+				logger.Info("alertmanager instance is reachable again")
+			}
+
+			if !p.setHealth(err) {
+				return
+			}
+
+			if err != nil {
+				logger.WithField("peer", p.url).WithError(err).Warn("alertmanager peer is unhealthy")
+			} else {
+				logger.WithField("peer", p.url).Info("alertmanager peer is healthy again")
+			}
+		}(p)
+	}
+
+	wg.Wait()
+}
+
+// RunHealthChecks health-checks all peers of all instances on the provided
+// interval, until the context is canceled.
+func (i *Instances) RunHealthChecks(ctx context.Context, interval time.Duration) {
+	check := func() {
+		var wg sync.WaitGroup
+
+		for _, client := range i.list {
+			wg.Add(1)
+
+			go func(client *Client) {
+				defer wg.Done()
+				client.HealthCheck(ctx)
+			}(client)
+		}
+
+		wg.Wait()
+	}
+
+	check()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
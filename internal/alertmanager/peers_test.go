@@ -0,0 +1,116 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lrstanley/discord-alertmanager/internal/models"
+	"github.com/prometheus/alertmanager/api/v2/client/general"
+)
+
+func testPeersClient(t *testing.T, urls ...string) *Client {
+	t.Helper()
+
+	c, err := NewClient("test", urls, models.ConfigAlertmanager{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+// closedServerURL returns the URL of a server which is no longer listening, so
+// connections to it are refused.
+func closedServerURL(t *testing.T) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	return srv.URL
+}
+
+func TestSubmitPeersFailover(t *testing.T) {
+	failing, failingHits := testServer(t, http.StatusServiceUnavailable)
+	healthy, healthyHits := testServer(t, http.StatusOK)
+
+	c := testPeersClient(t, failing.URL, healthy.URL)
+
+	if _, err := c.General.GetStatus(general.NewGetStatusParams(), c.HandleAuth); err != nil {
+		t.Fatalf("expected GET to fail over to the healthy peer: %v", err)
+	}
+
+	if failingHits(http.MethodGet) != 1 || healthyHits(http.MethodGet) != 1 {
+		t.Errorf("got %d/%d GET requests, want 1/1", failingHits(http.MethodGet), healthyHits(http.MethodGet))
+	}
+
+	// The failing peer is now unhealthy, so reset its health to make sure it's
+	// tried first again.
+	c.peers[0].setHealth(nil)
+
+	// The failing peer may have applied the silence before failing, so it must
+	// not be sent to another peer.
+	if err := postTestSilence(c); err == nil {
+		t.Fatal("expected POST not to fail over")
+	}
+
+	if failingHits(http.MethodPost) != 1 || healthyHits(http.MethodPost) != 0 {
+		t.Errorf("got %d/%d POST requests, want 1/0", failingHits(http.MethodPost), healthyHits(http.MethodPost))
+	}
+}
+
+func TestSubmitPeersFailoverRefused(t *testing.T) {
+	healthy, hits := testServer(t, http.StatusOK)
+
+	c := testPeersClient(t, closedServerURL(t), healthy.URL)
+
+	// Refused connections never reached the peer, so even non-idempotent requests
+	// can fail over.
+	if err := postTestSilence(c); err != nil {
+		t.Fatalf("expected POST to fail over to the healthy peer: %v", err)
+	}
+
+	if got := hits(http.MethodPost); got != 1 {
+		t.Errorf("got %d POST requests, want 1", got)
+	}
+}
+
+func TestSubmitContextDeadline(t *testing.T) {
+	srv, hits := testServer(t, http.StatusServiceUnavailable)
+
+	c, err := NewClient("test", []string{srv.URL}, models.ConfigAlertmanager{
+		Retries:      10,
+		RetryBackoff: time.Second,
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	params := general.NewGetStatusParams()
+	params.SetContext(ctx)
+
+	if _, err = c.General.GetStatus(params, c.HandleAuth); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Retries stop once the context is done, rather than waiting on the backoff.
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %s, want it cut short by the context deadline", elapsed)
+	}
+
+	if got := hits(http.MethodGet); got != 1 {
+		t.Errorf("got %d GET requests, want 1", got)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/go-openapi/runtime"
+)
+
+// CircuitOpenError is returned instead of sending requests to an instance which
+// has been consistently failing, until RetryAt.
+type CircuitOpenError struct {
+	Instance string
+	RetryAt  time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf(
+		"alertmanager instance %q is unreachable, retrying in %s",
+		e.Instance, time.Until(e.RetryAt).Round(time.Second),
+	)
+}
+
+// circuitBreaker rejects requests after a number of consecutive failures, until
+// a cooldown has passed. After the cooldown, a single request is let through,
+// which closes the circuit if it succeeds.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	// now returns the current time, and can be overridden in tests.
+	now func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func (b *circuitBreaker) time() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+
+	return time.Now()
+}
+
+func (b *circuitBreaker) isOpen() bool {
+	return b.threshold > 0 && b.failures >= b.threshold
+}
+
+// allow returns true if a request may be sent, otherwise when requests will be
+// allowed again.
+func (b *circuitBreaker) allow() (retryAt time.Time, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.isOpen() {
+		return time.Time{}, true
+	}
+
+	now := b.time()
+	if now.Before(b.openUntil) {
+		return b.openUntil, false
+	}
+
+	// Let this request through as a trial, holding off any others until it
+	// completes (or the cooldown passes again).
+	b.openUntil = now.Add(b.cooldown)
+	return time.Time{}, true
+}
+
+// state returns true and when requests will be allowed again, if the circuit is
+// open.
+func (b *circuitBreaker) state() (retryAt time.Time, open bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.openUntil, b.isOpen()
+}
+
+// success closes the circuit, returning true if it was open.
+func (b *circuitBreaker) success() (closed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	closed = b.isOpen()
+	b.failures = 0
+
+	return closed
+}
+
+// failure records a failed request, returning true if it opened the circuit.
+func (b *circuitBreaker) failure() (opened bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasOpen := b.isOpen()
+	b.failures++
+
+	if b.isOpen() {
+		b.openUntil = b.time().Add(b.cooldown)
+	}
+
+	return !wasOpen && b.isOpen()
+}
+
+// CircuitOpen returns true if requests to the instance are currently being
+// rejected, and when they will be allowed again.
+func (c *Client) CircuitOpen() (retryAt time.Time, open bool) {
+	return c.breaker.state()
+}
+
+// isIdempotent returns true if requests with the provided method can safely be
+// retried. Notably, creating silences (POST) is not, as it could result in
+// duplicate silences.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isResponse returns true if the error is a response from Alertmanager (e.g. a
+// 404), meaning it is reachable.
+func isResponse(err error) bool {
+	var clientErr interface{ IsClientError() bool }
+	var apiErr *runtime.APIError
+
+	return errors.As(err, &clientErr) || errors.As(err, &apiErr)
+}
+
+// backoff returns how long to wait before the provided retry attempt (starting
+// at 0), using exponential backoff with jitter.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.retryBackoff << attempt
+	if d <= 0 || (c.retryMaxBackoff > 0 && d > c.retryMaxBackoff) {
+		d = c.retryMaxBackoff
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	// Somewhere between half and the full backoff, so retries from multiple
+	// requests don't all happen at once.
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1)) //nolint:gosec
+}
+
+// Submit implements runtime.ClientTransport, sending the request to a healthy
+// peer, and failing over to the next peer on connection errors or 5xx responses
+// (only if the peer never received the request, for non-idempotent requests).
+// If all peers fail, idempotent requests are retried with backoff, until the
+// request context is done. Instances
+// which consistently fail have their requests rejected immediately, until the
+// circuit breaker cooldown has passed.
+func (c *Client) Submit(op *runtime.ClientOperation) (result any, err error) {
+	if retryAt, ok := c.breaker.allow(); !ok {
+		return nil, &CircuitOpenError{Instance: c.Name, RetryAt: retryAt}
+	}
+
+	ctx := op.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	retries := 0
+	if isIdempotent(op.Method) {
+		retries = c.retries
+	}
+
+	for attempt := 0; ; attempt++ {
+		result, err = c.submitPeers(op)
+		if !shouldFailover(ctx, err) || attempt >= retries {
+			break
+		}
+
+		timer := time.NewTimer(c.backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+	}
+
+	logger := log.FromContext(ctx).WithFields(log.Fields{
+		"src":      "alertmanager",
+		"instance": c.Name,
+	})
+
+	switch {
+	case shouldFailover(ctx, err):
+		if c.breaker.failure() {
+			retryAt, _ := c.breaker.state()
+			logger.WithError(err).WithField("retry_at", retryAt).Warn("alertmanager instance is unreachable, rejecting requests")
+		}
+	case err == nil || isResponse(err):
+		if c.breaker.success() {
+			logger.Info("alertmanager instance is reachable again")
+		}
+	}
+
+	return result, err
+}
@@ -0,0 +1,299 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/lrstanley/discord-alertmanager/internal/models"
+	"github.com/prometheus/alertmanager/api/v2/client/general"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := &testClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := &circuitBreaker{threshold: 3, cooldown: 30 * time.Second, now: clock.Now}
+
+	// Closed.
+	for i := 0; i < 2; i++ {
+		if _, ok := b.allow(); !ok {
+			t.Fatal("expected closed circuit to allow requests")
+		}
+
+		if b.failure() {
+			t.Fatalf("circuit opened after %d failure(s), want 3", i+1)
+		}
+	}
+
+	if !b.failure() {
+		t.Fatal("expected circuit to open after 3 failures")
+	}
+
+	// Open.
+	retryAt, ok := b.allow()
+	if ok {
+		t.Fatal("expected open circuit to reject requests")
+	}
+
+	if want := clock.now.Add(30 * time.Second); !retryAt.Equal(want) {
+		t.Fatalf("got retry at %s, want %s", retryAt, want)
+	}
+
+	if _, open := b.state(); !open {
+		t.Fatal("expected state to report open circuit")
+	}
+
+	// Half-open: a single trial request is allowed once the cooldown has passed,
+	// and fails.
+	clock.Advance(30 * time.Second)
+
+	if _, ok = b.allow(); !ok {
+		t.Fatal("expected a trial request after the cooldown")
+	}
+
+	if _, ok = b.allow(); ok {
+		t.Fatal("expected requests during the trial request to be rejected")
+	}
+
+	if b.failure() {
+		t.Fatal("expected failed trial request not to report the circuit as newly opened")
+	}
+
+	if retryAt, ok = b.allow(); ok || !retryAt.Equal(clock.now.Add(30*time.Second)) {
+		t.Fatalf("expected failed trial request to restart the cooldown, got retry at %s (ok: %v)", retryAt, ok)
+	}
+
+	// Half-open, and the trial request succeeds.
+	clock.Advance(31 * time.Second)
+
+	if _, ok = b.allow(); !ok {
+		t.Fatal("expected a trial request after the cooldown")
+	}
+
+	if !b.success() {
+		t.Fatal("expected successful trial request to close the circuit")
+	}
+
+	// Closed again.
+	if _, ok = b.allow(); !ok {
+		t.Fatal("expected closed circuit to allow requests")
+	}
+
+	if _, open := b.state(); open {
+		t.Fatal("expected state to report closed circuit")
+	}
+
+	if b.success() {
+		t.Fatal("expected success on a closed circuit not to report it as closed")
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := &circuitBreaker{threshold: 0, cooldown: 30 * time.Second}
+
+	for i := 0; i < 10; i++ {
+		if b.failure() {
+			t.Fatal("expected disabled circuit breaker to never open")
+		}
+	}
+
+	if _, ok := b.allow(); !ok {
+		t.Fatal("expected disabled circuit breaker to allow requests")
+	}
+}
+
+func TestIsIdempotent(t *testing.T) {
+	for method, want := range map[string]bool{
+		http.MethodGet:    true,
+		http.MethodHead:   true,
+		http.MethodPut:    true,
+		http.MethodDelete: true,
+		http.MethodPost:   false,
+		http.MethodPatch:  false,
+	} {
+		if got := isIdempotent(method); got != want {
+			t.Errorf("%s: got %v, want %v", method, got, want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Client{retryBackoff: 100 * time.Millisecond, retryMaxBackoff: time.Second}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{attempt: 0, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{attempt: 1, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{attempt: 3, min: 400 * time.Millisecond, max: 800 * time.Millisecond},
+		{attempt: 4, min: 500 * time.Millisecond, max: time.Second},   // Capped.
+		{attempt: 100, min: 500 * time.Millisecond, max: time.Second}, // Overflow.
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			if got := c.backoff(tt.attempt); got < tt.min || got > tt.max {
+				t.Fatalf("attempt %d: got %s, want between %s and %s", tt.attempt, got, tt.min, tt.max)
+			}
+		}
+	}
+
+	if got := (&Client{}).backoff(3); got != 0 {
+		t.Fatalf("got %s, want no backoff when unconfigured", got)
+	}
+}
+
+// testServer returns a server which always responds with the provided status,
+// counting requests by method.
+func testServer(t *testing.T, status int) (srv *httptest.Server, hits func(method string) int) {
+	t.Helper()
+
+	var mu sync.Mutex
+	counts := make(map[string]int)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		counts[r.Method]++
+		mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func(method string) int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return counts[method]
+	}
+}
+
+func testRetryClient(t *testing.T, url string, threshold int) *Client {
+	t.Helper()
+
+	c, err := NewClient("test", []string{url}, models.ConfigAlertmanager{
+		Retries:                 2,
+		RetryBackoff:            time.Millisecond,
+		RetryMaxBackoff:         5 * time.Millisecond,
+		CircuitBreakerThreshold: threshold,
+		CircuitBreakerCooldown:  time.Minute,
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func postTestSilence(c *Client) error {
+	now := time.Now()
+
+	_, err := c.Silence.PostSilences(silence.NewPostSilencesParams().WithSilence(&almodels.PostableSilence{
+		Silence: almodels.Silence{
+			Comment:   models.Ptr("test"),
+			CreatedBy: models.Ptr("test"),
+			Matchers: almodels.Matchers{{
+				Name:    models.Ptr("alertname"),
+				Value:   models.Ptr("Test"),
+				IsEqual: models.Ptr(true),
+				IsRegex: models.Ptr(false),
+			}},
+			StartsAt: models.Ptr(strfmt.DateTime(now)),
+			EndsAt:   models.Ptr(strfmt.DateTime(now.Add(time.Hour))),
+		},
+	}), c.HandleAuth)
+
+	return err
+}
+
+func TestSubmitRetries(t *testing.T) {
+	srv, hits := testServer(t, http.StatusServiceUnavailable)
+	c := testRetryClient(t, srv.URL, 0)
+
+	if _, err := c.General.GetStatus(general.NewGetStatusParams(), c.HandleAuth); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if got := hits(http.MethodGet); got != 3 {
+		t.Errorf("got %d GET requests, want 3 (1 + 2 retries)", got)
+	}
+
+	if err := postTestSilence(c); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if got := hits(http.MethodPost); got != 1 {
+		t.Errorf("got %d POST requests, want 1 (not retried)", got)
+	}
+}
+
+func TestSubmitNoRetryOnClientError(t *testing.T) {
+	srv, hits := testServer(t, http.StatusBadRequest)
+	c := testRetryClient(t, srv.URL, 1)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.General.GetStatus(general.NewGetStatusParams(), c.HandleAuth); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+
+	// 4xx responses mean Alertmanager is reachable, so they aren't retried, and
+	// don't open the circuit.
+	if got := hits(http.MethodGet); got != 3 {
+		t.Errorf("got %d GET requests, want 3", got)
+	}
+
+	if _, open := c.CircuitOpen(); open {
+		t.Error("expected circuit to be closed")
+	}
+}
+
+func TestSubmitCircuitOpen(t *testing.T) {
+	srv, hits := testServer(t, http.StatusServiceUnavailable)
+	c := testRetryClient(t, srv.URL, 2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.General.GetStatus(general.NewGetStatusParams(), c.HandleAuth); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+
+	if _, open := c.CircuitOpen(); !open {
+		t.Fatal("expected circuit to be open")
+	}
+
+	before := hits(http.MethodGet)
+
+	_, err := c.General.GetStatus(general.NewGetStatusParams(), c.HandleAuth)
+
+	var circuitErr *CircuitOpenError
+	if !errors.As(err, &circuitErr) {
+		t.Fatalf("got error %v, want CircuitOpenError", err)
+	}
+
+	if circuitErr.Instance != "test" {
+		t.Errorf("got instance %q, want %q", circuitErr.Instance, "test")
+	}
+
+	if got := hits(http.MethodGet); got != before {
+		t.Errorf("got %d requests while the circuit was open, want none", got-before)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package alertmanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WebhookVersion is the only webhook payload version currently supported.
+const WebhookVersion = "4"
+
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// WebhookMessage is the payload sent by Alertmanager to webhook receivers. See
+// https://prometheus.io/docs/alerting/latest/configuration/#webhook_config for
+// more information.
+type WebhookMessage struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   uint64            `json:"truncatedAlerts"`
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	Alerts            []*WebhookAlert   `json:"alerts"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+}
+
+// WebhookAlert is a single alert within a webhook payload.
+type WebhookAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// IsResolved returns true if the alert has been resolved.
+func (a *WebhookAlert) IsResolved() bool {
+	return a.Status == StatusResolved
+}
+
+// ParseWebhookMessage decodes and validates a webhook payload.
+func ParseWebhookMessage(r io.Reader) (*WebhookMessage, error) {
+	msg := &WebhookMessage{}
+
+	if err := json.NewDecoder(r).Decode(msg); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook payload: %w", err)
+	}
+
+	if msg.Version != WebhookVersion {
+		return nil, fmt.Errorf("unsupported webhook payload version %q (expected %q)", msg.Version, WebhookVersion)
+	}
+
+	if len(msg.Alerts) == 0 {
+		return nil, errors.New("webhook payload contains no alerts")
+	}
+
+	return msg, nil
+}
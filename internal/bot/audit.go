@@ -0,0 +1,230 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/apex/log"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/lrstanley/discord-alertmanager/internal/models"
+	"github.com/lrstanley/discord-alertmanager/internal/store"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+)
+
+const (
+	auditCreated = "created"
+	auditUpdated = "updated"
+	auditRemoved = "removed"
+
+	auditPruneInterval = 1 * time.Hour
+)
+
+// auditEntry is a record of a change to a silence.
+type auditEntry struct {
+	action   string // One of auditCreated, auditUpdated, auditRemoved.
+	source   string
+	al       *alertmanager.Client
+	previous *almodels.GettableSilence // Nil when created.
+	current  *almodels.GettableSilence // Nil when removed.
+
+	// message is the response to the interaction, fetched if not provided.
+	message *disgord.Message
+}
+
+// interactionSource returns a human readable description of how the interaction
+// was triggered.
+func interactionSource(h *disgord.InteractionCreate) string {
+	switch h.Type {
+	case disgord.InteractionApplicationCommand:
+		if h.Data.Type == disgord.ApplicationCommandMessage {
+			return fmt.Sprintf("message command (%s)", h.Data.Name)
+		}
+
+		return "slash command"
+	case disgord.InteractionModalSubmit:
+		return "modal"
+	case disgord.InteractionMessageComponent:
+		return "button"
+	default:
+		return "unknown"
+	}
+}
+
+// parseAuditChannels parses guild-id to channel-id mappings.
+func parseAuditChannels(input map[string]string) (map[disgord.Snowflake]disgord.Snowflake, error) {
+	channels := make(map[disgord.Snowflake]disgord.Snowflake, len(input))
+
+	for guild, channel := range input {
+		gid := disgord.ParseSnowflakeString(guild)
+		cid := disgord.ParseSnowflakeString(channel)
+
+		if gid.IsZero() || cid.IsZero() {
+			return nil, fmt.Errorf("invalid audit channel %q:%q: expected <guild-id>:<channel-id>", guild, channel)
+		}
+
+		channels[gid] = cid
+	}
+
+	return channels, nil
+}
+
+func silenceTimeRange(alertSilence *almodels.GettableSilence) string {
+	return fmt.Sprintf(
+		"<t:%d:f> → <t:%d:f>",
+		time.Time(*alertSilence.StartsAt).Unix(),
+		time.Time(*alertSilence.EndsAt).Unix(),
+	)
+}
+
+func silenceMatchersBlock(alertSilence *almodels.GettableSilence) string {
+	return truncate("```\n"+strings.Join(alertmanager.MatcherToString(alertSilence.Matchers, true), "\n"), 1020) + "\n```" //nolint:gomnd
+}
+
+// auditChannel returns the audit channel for the guild, preferring the guild
+// settings over the configured flags.
+func (b *Bot) auditChannel(guildID disgord.Snowflake) (disgord.Snowflake, bool) {
+	settings, err := b.store.GuildSettings(b.ctx, guildID)
+	if err == nil && !settings.AuditChannelID.IsZero() {
+		return settings.AuditChannelID, true
+	} else if err != nil && !errors.Is(err, store.ErrNotFound) {
+		b.logger.WithError(err).WithField("guild_id", guildID).Warn("failed to fetch guild settings")
+	}
+
+	channelID, ok := b.auditChannels[guildID]
+	return channelID, ok
+}
+
+// audit records the silence change in the audit history, and posts it to the
+// guild's audit channel, if one is configured. Must be called after responding
+// to the interaction, so the response can be linked to.
+func (b *Bot) audit(h *disgord.InteractionCreate, entry *auditEntry) {
+	logger := b.logger.WithFields(log.Fields{
+		"guild_id": h.GuildID,
+		"action":   entry.action,
+	})
+
+	target := entry.current
+	color := colorInfo
+
+	switch entry.action {
+	case auditCreated:
+		color = colorSuccess
+	case auditRemoved:
+		target = entry.previous
+		color = colorError
+	}
+
+	record := &store.AuditRecord{
+		Time:      time.Now(),
+		GuildID:   h.GuildID,
+		UserID:    h.Member.User.ID,
+		Action:    entry.action,
+		Source:    entry.source,
+		Instance:  entry.al.Name,
+		SilenceID: *target.ID,
+	}
+
+	if entry.message == nil {
+		var err error
+
+		entry.message, err = b.originalResponse(h)
+		if err != nil {
+			logger.WithError(err).Warn("failed to fetch original interaction response")
+		}
+	}
+
+	if entry.message != nil {
+		record.Link, _ = entry.message.DiscordURL()
+	}
+
+	fields := []*disgord.EmbedField{}
+
+	if entry.previous != nil {
+		record.PreviousMatchers = alertmanager.MatcherToString(entry.previous.Matchers, false)
+		record.PreviousStartsAt = models.Ptr(time.Time(*entry.previous.StartsAt))
+		record.PreviousEndsAt = models.Ptr(time.Time(*entry.previous.EndsAt))
+		if entry.current != nil {
+			record.PreviousSilenceID = *entry.previous.ID
+		}
+
+		fields = append(fields,
+			&disgord.EmbedField{Name: "Previous matchers", Value: silenceMatchersBlock(entry.previous)},
+			&disgord.EmbedField{Name: "Previous time range", Value: silenceTimeRange(entry.previous)},
+		)
+	}
+
+	if entry.current != nil {
+		record.Matchers = alertmanager.MatcherToString(entry.current.Matchers, false)
+		record.StartsAt = models.Ptr(time.Time(*entry.current.StartsAt))
+		record.EndsAt = models.Ptr(time.Time(*entry.current.EndsAt))
+
+		fields = append(fields,
+			&disgord.EmbedField{Name: "Matchers", Value: silenceMatchersBlock(entry.current)},
+			&disgord.EmbedField{Name: "Time range", Value: silenceTimeRange(entry.current)},
+			&disgord.EmbedField{Name: "Comment", Value: truncate(*entry.current.Comment, 1024)}, //nolint:gomnd
+		)
+	}
+
+	if field := b.instanceField(entry.al); field != nil {
+		fields = append(fields, field)
+	}
+
+	if err := b.store.AddAudit(b.ctx, record); err != nil {
+		logger.WithError(err).Error("failed to store audit record")
+	}
+
+	channelID, ok := b.auditChannel(h.GuildID)
+	if !ok {
+		return
+	}
+
+	description := fmt.Sprintf("<@%d> %s a silence via %s", h.Member.User.ID, entry.action, entry.source)
+	if record.Link != "" {
+		description += fmt.Sprintf(" in [this message](%s)", record.Link)
+	} else {
+		description += fmt.Sprintf(" in <#%d>", h.ChannelID)
+	}
+
+	_, err := b.client.Channel(channelID).WithContext(b.ctx).CreateMessage(&disgord.CreateMessage{
+		// Don't ping anyone in the audit channel.
+		AllowedMentions: &disgord.AllowedMentions{Parse: []string{}},
+		Embeds: []*disgord.Embed{{
+			Type:        disgord.EmbedTypeRich,
+			Color:       color,
+			Title:       fmt.Sprintf("Silence %s: %s", entry.action, *target.ID),
+			URL:         entry.al.SilenceURL(*target.ID),
+			Description: description,
+			Fields:      fields,
+			Timestamp:   disgord.Time{Time: record.Time},
+		}},
+	})
+	if err != nil {
+		logger.WithField("channel_id", channelID).WithError(err).Error("failed to post audit log entry")
+	}
+}
+
+// runAuditPruning periodically removes audit history older than the configured
+// retention.
+func (b *Bot) runAuditPruning(ctx context.Context) {
+	ticker := time.NewTicker(auditPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.store.PruneAudit(ctx, time.Now().Add(-b.config.Store.AuditRetention)); err != nil {
+				b.logger.WithError(err).Warn("failed to prune audit history")
+			}
+		}
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/go-openapi/strfmt"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	maxAutocompleteChoices = 25
+	maxChoiceLength        = 100
+)
+
+type autocompleteChoice struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// autocompleteHandler returns the suggestions for the value the user has typed
+// so far.
+type autocompleteHandler func(h *disgord.InteractionCreate, value string) ([]*autocompleteChoice, error)
+
+// autocompleteOption is an option which supports autocomplete. complete reports
+// whether the value looks fully entered, which is used to determine which option
+// the user is currently typing in.
+type autocompleteOption struct {
+	handler  autocompleteHandler
+	complete func(h *disgord.InteractionCreate, value string) bool
+}
+
+func (b *Bot) autocompleteOptions() map[string]*autocompleteOption {
+	filter := &autocompleteOption{
+		handler: b.autocompleteFilter,
+		complete: func(_ *disgord.InteractionCreate, value string) bool {
+			_, err := alertmanager.ParseLabels(value, true)
+			return err == nil && strings.HasSuffix(strings.TrimSpace(value), `"`)
+		},
+	}
+
+	return map[string]*autocompleteOption{
+		"id": {
+			handler:  b.autocompleteSilenceID,
+			complete: func(_ *disgord.InteractionCreate, value string) bool { return strfmt.IsUUID(value) },
+		},
+		"instance": {
+			handler: b.autocompleteInstance,
+			complete: func(_ *disgord.InteractionCreate, value string) bool {
+				_, ok := b.instances.Get(value)
+				return ok
+			},
+		},
+		"filter": filter,
+		// Label sets, e.g. for testing routes.
+		"labels": filter,
+		"receiver": {
+			handler: b.autocompleteReceiver,
+			complete: func(h *disgord.InteractionCreate, value string) bool {
+				ctx, cancel := b.interactionContext(h)
+				defer cancel()
+
+				names, err := b.cachedReceivers(ctx, b.instance(h))
+				return err == nil && slices.Contains(names, value)
+			},
+		},
+	}
+}
+
+// autocompleteFocus returns the option the user is most likely typing in. Discord
+// flags the focused option, however disgord doesn't expose it, so we pick the last
+// autocomplete-enabled option which doesn't look fully entered yet, falling back
+// to the last autocomplete-enabled option.
+func autocompleteFocus(
+	h *disgord.InteractionCreate,
+	options []*disgord.ApplicationCommandDataOption,
+	handlers map[string]*autocompleteOption,
+) (focused *disgord.ApplicationCommandDataOption) {
+	var fallback *disgord.ApplicationCommandDataOption
+
+	for _, opt := range options {
+		handler, ok := handlers[opt.Name]
+		if !ok {
+			continue
+		}
+
+		fallback = opt
+
+		if value, _ := opt.Value.(string); !handler.complete(h, value) {
+			focused = opt
+		}
+	}
+
+	if focused == nil {
+		return fallback
+	}
+
+	return focused
+}
+
+// onAutocomplete responds to autocomplete interactions with suggestions for the
+// focused option.
+func (b *Bot) onAutocomplete(s disgord.Session, h *disgord.InteractionCreate) {
+	var choices []*autocompleteChoice
+
+	// Autocomplete can't respond with an error, so just don't suggest anything if
+	// the user isn't allowed to view silences/alerts.
+	if b.allowed(s, h, actionView) && len(h.Data.Options) > 0 {
+		options := h.Data.Options
+		if options[0].Type == disgord.OptionTypeSubCommand {
+			options = options[0].Options
+		}
+
+		handlers := b.autocompleteOptions()
+
+		if focused := autocompleteFocus(h, options, handlers); focused != nil {
+			value, _ := focused.Value.(string)
+
+			var err error
+
+			choices, err = handlers[focused.Name].handler(h, value)
+			if err != nil {
+				b.logger.WithError(err).WithField("option", focused.Name).Warn("failed to generate autocomplete choices")
+			}
+		}
+	}
+
+	if len(choices) > maxAutocompleteChoices {
+		choices = choices[:maxAutocompleteChoices]
+	}
+
+	if choices == nil {
+		choices = []*autocompleteChoice{}
+	}
+
+	// disgord doesn't support sending autocomplete choices.
+	err := b.interactionRequest(
+		b.ctx,
+		http.MethodPost,
+		fmt.Sprintf("/interactions/%d/%s/callback", h.ID, h.Token),
+		map[string]any{
+			"type": disgord.InteractionCallbackApplicationCommandAutocompleteResult,
+			"data": map[string]any{"choices": choices},
+		},
+		nil,
+	)
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to autocomplete interaction")
+	}
+}
+
+// silenceChoiceName returns a short description of the silence, for use as an
+// autocomplete choice.
+func silenceChoiceName(alertSilence *almodels.GettableSilence) string {
+	return truncate(fmt.Sprintf(
+		"%s — %s (ends in %s)",
+		*alertSilence.Comment,
+		strings.Join(alertmanager.MatcherToString(alertSilence.Matchers, false), ", "),
+		time.Until(time.Time(*alertSilence.EndsAt)).Round(time.Minute),
+	), maxChoiceLength)
+}
+
+// autocompleteSilenceID suggests active silences, matching the input against
+// the silence ID, comment and matchers.
+func (b *Bot) autocompleteSilenceID(h *disgord.InteractionCreate, value string) ([]*autocompleteChoice, error) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	silences, err := b.fetchSilences(ctx, &silenceQuery{al: b.instance(h), sort: sortEndsSoonest})
+	if err != nil {
+		return nil, err
+	}
+
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	var choices []*autocompleteChoice
+
+	for _, alertSilence := range silences {
+		if value != "" && !strings.Contains(*alertSilence.ID, value) &&
+			!strings.Contains(strings.ToLower(*alertSilence.Comment), value) &&
+			!strings.Contains(strings.ToLower(strings.Join(alertmanager.MatcherToString(alertSilence.Matchers, false), " ")), value) {
+			continue
+		}
+
+		choices = append(choices, &autocompleteChoice{
+			Name:  silenceChoiceName(alertSilence),
+			Value: *alertSilence.ID,
+		})
+
+		if len(choices) >= maxAutocompleteChoices {
+			break
+		}
+	}
+
+	return choices, nil
+}
+
+// autocompleteInstance suggests configured Alertmanager instances.
+func (b *Bot) autocompleteInstance(_ *disgord.InteractionCreate, value string) ([]*autocompleteChoice, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	var choices []*autocompleteChoice
+
+	for _, al := range b.instances.List() {
+		if value != "" && !strings.Contains(strings.ToLower(al.Name), value) {
+			continue
+		}
+
+		choices = append(choices, &autocompleteChoice{
+			Name:  truncate(fmt.Sprintf("%s (%s)", al.Name, al.URL()), maxChoiceLength),
+			Value: al.Name,
+		})
+	}
+
+	return choices, nil
+}
+
+// autocompleteReceiver suggests receivers known to Alertmanager.
+func (b *Bot) autocompleteReceiver(h *disgord.InteractionCreate, value string) ([]*autocompleteChoice, error) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	names, err := b.cachedReceivers(ctx, b.instance(h))
+	if err != nil {
+		return nil, err
+	}
+
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	var choices []*autocompleteChoice
+
+	for _, name := range names {
+		if value != "" && !strings.Contains(strings.ToLower(name), value) {
+			continue
+		}
+
+		choices = append(choices, &autocompleteChoice{
+			Name:  truncate(name, maxChoiceLength),
+			Value: name,
+		})
+	}
+
+	return choices, nil
+}
+
+// autocompleteFilter suggests label names and values for filters/matchers, based
+// on currently firing alerts. Suggested values are narrowed down by the matchers
+// which have already been entered.
+func (b *Bot) autocompleteFilter(h *disgord.InteractionCreate, value string) ([]*autocompleteChoice, error) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	partial, err := alertmanager.ParsePartialLabels(value)
+	if err != nil {
+		// Most likely invalid syntax, which the user has to fix first.
+		return nil, nil //nolint:nilerr
+	}
+
+	alerts, err := b.cachedAlerts(ctx, b.instance(h))
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err = filterAlerts(alerts, partial.Matchers)
+	if err != nil {
+		// Invalid regex, which the user has to fix first.
+		return nil, nil //nolint:nilerr
+	}
+
+	counts := make(map[string]int)
+
+	for _, alertEntry := range alerts {
+		if !partial.IsValue {
+			for name := range alertEntry.Labels {
+				counts[name]++
+			}
+
+			continue
+		}
+
+		if v, ok := alertEntry.Labels[partial.Name]; ok {
+			counts[v]++
+		}
+	}
+
+	// Don't suggest label names which have already been entered.
+	if !partial.IsValue {
+		for _, m := range partial.Matchers {
+			delete(counts, *m.Name)
+		}
+	}
+
+	search := strings.ToLower(partial.Partial)
+
+	candidates := maps.Keys(counts)
+	slices.SortFunc(candidates, func(x, y string) bool {
+		// Prefix matches first, then the most common, then alphabetically.
+		xp, yp := strings.HasPrefix(strings.ToLower(x), search), strings.HasPrefix(strings.ToLower(y), search)
+		if xp != yp {
+			return xp
+		}
+
+		if counts[x] != counts[y] {
+			return counts[x] > counts[y]
+		}
+
+		return x < y
+	})
+
+	var choices []*autocompleteChoice
+
+	for _, candidate := range candidates {
+		if !strings.Contains(strings.ToLower(candidate), search) {
+			continue
+		}
+
+		completed := partial.Complete(candidate)
+
+		// Discord limits choice values to 100 characters.
+		if len([]rune(completed)) > maxChoiceLength {
+			continue
+		}
+
+		choices = append(choices, &autocompleteChoice{Name: completed, Value: completed})
+
+		if len(choices) >= maxAutocompleteChoices {
+			break
+		}
+	}
+
+	return choices, nil
+}
@@ -8,8 +8,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/andersfylling/disgord"
@@ -18,37 +19,83 @@ import (
 	"github.com/kr/pretty"
 	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
 	"github.com/lrstanley/discord-alertmanager/internal/models"
+	"github.com/lrstanley/discord-alertmanager/internal/store"
 	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
 )
 
-const httpRequestTimeout = 5 * time.Second
+const (
+	httpRequestTimeout = 5 * time.Second
+
+	// interactionTimeout is how long all Alertmanager requests (including
+	// failover and retries) made before responding to an interaction may take,
+	// as Discord requires a response within 3 seconds.
+	interactionTimeout = 2 * time.Second
+)
 
 type Bot struct {
 	ctx    context.Context
-	config models.ConfigDiscord
+	config models.Flags
 	logger log.Interface
 	debug  bool
 
 	client *disgord.Client
 	self   *disgord.User
 
-	al *alertmanager.Client
+	instances *alertmanager.Instances
+	store     store.Store
+
+	rbac          []*rbacRule
+	auditChannels map[disgord.Snowflake]disgord.Snowflake
+
+	alertGroupLocks keyedMutex
+	pendingSilences *ttlCache[*pendingSilence]
+	silenceQueries  *ttlCache[*silenceQuery]
+	receivers       *ttlCache[[]string]
+	alerts          *ttlCache[[]*almodels.GettableAlert]
+
+	// received tracks when the interactions currently being handled were received
+	// (disgord.Snowflake -> time.Time).
+	received sync.Map
 }
 
 // New creates a new bot instance. It will make a few calls to Discord to validate
 // the bot config. Make sure to call Run() to start the bot.
-func New(ctx context.Context, config models.ConfigDiscord, al *alertmanager.Client, debug bool) (b *Bot, err error) {
+func New(ctx context.Context, config models.Flags, instances *alertmanager.Instances, db store.Store, debug bool) (b *Bot, err error) {
 	b = &Bot{
-		ctx:    ctx,
-		config: config,
-		logger: log.FromContext(ctx).WithField("src", "bot"),
-		debug:  debug,
-		al:     al,
+		ctx:             ctx,
+		config:          config,
+		logger:          log.FromContext(ctx).WithField("src", "bot"),
+		debug:           debug,
+		instances:       instances,
+		store:           db,
+		pendingSilences: newTTLCache[*pendingSilence](pendingSilenceTTL),
+		silenceQueries:  newTTLCache[*silenceQuery](silenceQueryTTL),
+		receivers:       newTTLCache[[]string](receiversTTL),
+		alerts:          newTTLCache[[]*almodels.GettableAlert](alertsTTL),
+	}
+
+	b.rbac, err = parseRBACRules(b.config.RBAC.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	b.auditChannels, err = parseAuditChannels(b.config.Discord.AuditChannels)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.config.Webhook.Listen != "" && b.config.Webhook.Token == "" {
+		return nil, errors.New("a webhook token must be configured when listening for webhook notifications")
+	}
+
+	if b.config.Reminders.Before > 0 && b.config.Reminders.Interval <= 0 {
+		return nil, errors.New("reminder interval must be greater than 0")
 	}
 
 	b.client, err = disgord.NewClient(ctx, disgord.Config{
 		ProjectName: "discord-alertmanager (https://github.com/lrstanley/discord-alertmanager, https://liam.sh)",
-		BotToken:    b.config.Token,
+		BotToken:    b.config.Discord.Token,
 		Logger:      &discordLogger{logger: b.logger},
 		Presence: &disgord.UpdateStatusPayload{
 			Since: nil,
@@ -88,12 +135,40 @@ func (b *Bot) Run(ctx context.Context) error {
 		return err
 	}
 
+	// Listen before connecting to Discord, so we can bail early if the address
+	// is already in use, etc.
+	var listener net.Listener
+	if b.config.Webhook.Listen != "" {
+		listener, err = net.Listen("tcp", b.config.Webhook.Listen)
+		if err != nil {
+			b.logger.WithError(err).Error("failed to listen for webhook notifications")
+			return err
+		}
+	}
+
 	err = b.client.Gateway().Connect()
 	if err != nil {
 		b.logger.WithError(err).Error("failed to connect to discord")
 		return err
 	}
 
+	if listener != nil {
+		go b.runWebhookServer(ctx, listener)
+		go b.runAlertGroupPruning(ctx)
+	}
+
+	if b.config.Alertmanager.HealthCheckInterval > 0 {
+		go b.instances.RunHealthChecks(ctx, b.config.Alertmanager.HealthCheckInterval)
+	}
+
+	if b.config.Reminders.Before > 0 {
+		go b.runReminders(ctx)
+	}
+
+	if b.config.Store.AuditRetention > 0 {
+		go b.runAuditPruning(ctx)
+	}
+
 	<-ctx.Done()
 	b.logger.Info("shutting down")
 	_ = b.client.Gateway().Disconnect()
@@ -105,22 +180,50 @@ func (b *Bot) Run(ctx context.Context) error {
 // onReady is called when the bot is ready to start receiving events.
 func (b *Bot) onReady() {
 	b.logger.Info("updating application commands")
-	if err := b.client.ApplicationCommand(b.self.ID).Global().BulkOverwrite(commands); err != nil {
+	if err := b.client.ApplicationCommand(b.self.ID).Global().BulkOverwrite(b.applicationCommands()); err != nil {
 		b.logger.WithError(err).Fatal("failed to update application commands")
 	}
 }
 
 // onInteractionCreate is called when a user interacts with the bots slash commands.
+// interactionContext returns a context for Alertmanager requests made before
+// responding to the interaction, which is shared by all of them, and canceled
+// before Discord's response deadline.
+func (b *Bot) interactionContext(h *disgord.InteractionCreate) (context.Context, context.CancelFunc) {
+	received := time.Now()
+	if v, ok := b.received.Load(h.ID); ok {
+		received = v.(time.Time)
+	}
+
+	return context.WithDeadline(b.ctx, received.Add(interactionTimeout))
+}
+
 func (b *Bot) onInteractionCreate(s disgord.Session, h *disgord.InteractionCreate) {
 	b.logger.WithField("event", fmt.Sprintf("% #v", pretty.Formatter(*h))).Debug("received interaction create event")
 
-	customID := h.Data.CustomID
-	var args []string
+	b.received.Store(h.ID, time.Now())
+	defer b.received.Delete(h.ID)
+
+	// Interactions from direct messages (e.g. reminders) don't include a member,
+	// only the user.
+	if h.Member == nil && h.User != nil {
+		h.Member = &disgord.Member{User: h.User}
+	}
+
+	if h.Type == disgord.InteractionApplicationCommandAutocomplete {
+		b.onAutocomplete(s, h)
+		return
+	}
+
+	customID, _, args := splitCustomID(h.Data.CustomID)
+
+	if !b.authorize(s, h, interactionAction(h, customID)) {
+		return
+	}
 
-	// Custom IDs that have a specific prefix, and provided arguments.
-	if i := strings.Index(h.Data.CustomID, "/"); i != -1 {
-		customID = h.Data.CustomID[:i]
-		args = strings.Split(h.Data.CustomID[i+1:], "/")
+	if _, err := b.resolveInstance(h); err != nil {
+		b.responseError(s, h, "Invalid Alertmanager instance", err)
+		return
 	}
 
 	switch customID {
@@ -130,6 +233,51 @@ func (b *Bot) onInteractionCreate(s disgord.Session, h *disgord.InteractionCreat
 	case "modal-edit":
 		b.silenceEditFromModalCallback(s, h, customID, args)
 		return
+	case "silence-extend":
+		b.silenceExtendFromButton(s, h, customID, args)
+		return
+	case "silence-expire":
+		b.silenceExpireFromButton(s, h, customID, args)
+		return
+	case "silence-expire-confirm":
+		b.silenceExpireConfirmFromButton(s, h, customID, args)
+		return
+	case "silence-expire-cancel":
+		b.silenceExpireCancelFromButton(s, h, customID, args)
+		return
+	case "silence-clone":
+		b.silenceCloneFromButton(s, h, customID, args)
+		return
+	case "silence-edit":
+		b.silenceEditFromButton(s, h, customID, args)
+		return
+	case "silence-confirm":
+		b.silenceConfirmFromButton(s, h, customID, args)
+		return
+	case "silence-cancel":
+		b.silenceCancelFromButton(s, h, customID, args)
+		return
+	case "modal-alert-fire":
+		b.alertFireFromModalCallback(s, h, customID, args)
+		return
+	case "alert-resolve":
+		b.alertResolveFromButton(s, h, customID, args)
+		return
+	case "alert-group-silence":
+		b.alertGroupSilenceFromButton(s, h, customID, args)
+		return
+	case "reminder-dismiss":
+		b.reminderDismissFromButton(s, h, customID, args)
+		return
+	case "silences-page":
+		b.silenceListPageFromButton(s, h, customID, args)
+		return
+	case "silences-jump":
+		b.silenceListJumpFromButton(s, h, customID, args)
+		return
+	case "modal-silences-jump":
+		b.silenceListJumpFromModalCallback(s, h, customID, args)
+		return
 	}
 
 	switch h.Data.Name {
@@ -160,6 +308,54 @@ func (b *Bot) onInteractionCreate(s disgord.Session, h *disgord.InteractionCreat
 			b.silenceRemoveFromCommand(s, h)
 			return
 		}
+	case "alerts": // Application commands.
+		switch h.Data.Options[0].Name {
+		case "list":
+			b.alertListFromCommand(s, h)
+			return
+		case "groups":
+			b.alertGroupsFromCommand(s, h)
+			return
+		case "fire":
+			b.alertFireFromCommand(s, h)
+			return
+		case "resolve":
+			b.alertResolveFromCommand(s, h)
+			return
+		}
+	case "alertmanager": // Application commands.
+		switch h.Data.Options[0].Name {
+		case "instances":
+			b.instanceListFromCommand(s, h)
+			return
+		case "status":
+			b.alertmanagerStatusFromCommand(s, h)
+			return
+		case "routes":
+			b.alertmanagerRoutesFromCommand(s, h)
+			return
+		case "route-test":
+			b.alertmanagerRouteTestFromCommand(s, h)
+			return
+		case "receivers":
+			b.receiverListFromCommand(s, h)
+			return
+		case "receiver-test":
+			b.receiverTestFromCommand(s, h)
+			return
+		case "set-default":
+			b.instanceSetDefaultFromCommand(s, h)
+			return
+		}
+	case "audit": // Application commands.
+		switch h.Data.Options[0].Name {
+		case "history":
+			b.auditHistoryFromCommand(s, h)
+			return
+		case "set-channel":
+			b.auditSetChannelFromCommand(s, h)
+			return
+		}
 	}
 
 	b.logger.WithFields(log.Fields{
@@ -173,6 +369,44 @@ func (b *Bot) onInteractionCreate(s disgord.Session, h *disgord.InteractionCreat
 }
 
 func (b *Bot) responseError(s disgord.Session, h *disgord.InteractionCreate, title string, originalErr error) {
+	err := s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Flags:  disgord.MessageFlagEphemeral,
+			Embeds: []*disgord.Embed{b.errorEmbed(h, title, originalErr)},
+			// Components: []*disgord.MessageComponent{{
+			// 	Type: disgord.MessageComponentActionRow,
+			// 	Components: []*disgord.MessageComponent{
+			// 		{
+			// 			Type:     disgord.MessageComponentButton,
+			// 			Label:    "retry",
+			// 			Style:    disgord.Primary,
+			// 			CustomID: "retry",
+			// 			Disabled: false,
+			// 		},
+			// 	},
+			// }},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+// deferredResponseError is the same as responseError, but for interactions which
+// have already been responded to with a deferred response.
+func (b *Bot) deferredResponseError(s disgord.Session, h *disgord.InteractionCreate, title string, originalErr error) {
+	err := s.EditInteractionResponse(b.ctx, h, &disgord.UpdateMessage{
+		Embeds: &[]*disgord.Embed{b.errorEmbed(h, title, originalErr)},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to update interaction response")
+	}
+}
+
+// errorEmbed logs the error, and returns an embed describing it, translating
+// common Alertmanager errors into something more user friendly.
+func (b *Bot) errorEmbed(h *disgord.InteractionCreate, title string, originalErr error) *disgord.Embed {
 	b.logger.WithFields(log.Fields{
 		"guild_id":   h.GuildID,
 		"channel_id": h.ChannelID,
@@ -182,6 +416,11 @@ func (b *Bot) responseError(s disgord.Session, h *disgord.InteractionCreate, tit
 
 	//nolint:errorlint,revive,stylecheck
 	switch terr := originalErr.(type) {
+	case *alertmanager.CircuitOpenError:
+		originalErr = fmt.Errorf(
+			"Alertmanager unreachable, retrying in %s. Please try again later.",
+			time.Until(terr.RetryAt).Round(time.Second),
+		)
 	case *silence.GetSilenceNotFound:
 		originalErr = errors.New("Alertmanager was unable to find the requested silence. Please check your inputs and try again.")
 	case *runtime.APIError:
@@ -205,33 +444,16 @@ func (b *Bot) responseError(s disgord.Session, h *disgord.InteractionCreate, tit
 				terr.Code,
 			)
 		}
+	default:
+		if errors.Is(originalErr, context.DeadlineExceeded) {
+			originalErr = errors.New("Alertmanager took too long to respond. Please try again later.")
+		}
 	}
 
-	err := s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
-		Type: disgord.InteractionCallbackChannelMessageWithSource,
-		Data: &disgord.CreateInteractionResponseData{
-			Flags: disgord.MessageFlagEphemeral,
-			Embeds: []*disgord.Embed{{
-				Type:        disgord.EmbedTypeRich,
-				Color:       colorError,
-				Title:       title,
-				Description: originalErr.Error(),
-			}},
-			// Components: []*disgord.MessageComponent{{
-			// 	Type: disgord.MessageComponentActionRow,
-			// 	Components: []*disgord.MessageComponent{
-			// 		{
-			// 			Type:     disgord.MessageComponentButton,
-			// 			Label:    "retry",
-			// 			Style:    disgord.Primary,
-			// 			CustomID: "retry",
-			// 			Disabled: false,
-			// 		},
-			// 	},
-			// }},
-		},
-	})
-	if err != nil {
-		b.logger.WithError(err).Error("failed to respond to interaction")
+	return &disgord.Embed{
+		Type:        disgord.EmbedTypeRich,
+		Color:       colorError,
+		Title:       title,
+		Description: originalErr.Error(),
 	}
 }
@@ -0,0 +1,79 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type ttlEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+// ttlCache is a small in-memory cache, used to hold state between interactions
+// (e.g. when a button is clicked, using randomly generated keys, as Discord custom
+// IDs are limited to 100 characters), or to avoid repeating requests to
+// Alertmanager (e.g. while autocompleting).
+type ttlCache[T any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*ttlEntry[T]
+}
+
+func newTTLCache[T any](ttl time.Duration) *ttlCache[T] {
+	return &ttlCache[T]{
+		ttl:     ttl,
+		entries: make(map[string]*ttlEntry[T]),
+	}
+}
+
+// Set stores the value, returning the key it can be fetched with.
+func (c *ttlCache[T]) Set(value T) string {
+	buf := make([]byte, 6) //nolint:gomnd
+	_, _ = rand.Read(buf)
+	key := hex.EncodeToString(buf)
+
+	c.SetKey(key, value)
+	return key
+}
+
+// SetKey stores the value under the provided key, replacing any existing value.
+func (c *ttlCache[T]) SetKey(key string, value T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, entry := range c.entries {
+		if time.Now().After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = &ttlEntry[T]{value: value, expires: time.Now().Add(c.ttl)}
+}
+
+// Get returns the value for the provided key, if it exists and hasn't expired.
+func (c *ttlCache[T]) Get(key string) (v T, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return v, false
+	}
+
+	return entry.value, true
+}
+
+// Delete removes the value for the provided key.
+func (c *ttlCache[T]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
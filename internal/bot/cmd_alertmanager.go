@@ -0,0 +1,503 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/lrstanley/discord-alertmanager/internal/store"
+	"github.com/prometheus/alertmanager/api/v2/client/general"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/slices"
+)
+
+// peerStateLine returns a single line summary of the health of a peer.
+func peerStateLine(state alertmanager.PeerState) string {
+	icon := ":green_circle:"
+	if !state.Healthy {
+		icon = ":red_circle:"
+	}
+
+	var detail string
+
+	switch {
+	case state.Error != nil:
+		detail = truncate(state.Error.Error(), 100) //nolint:gomnd
+	case state.Checked.IsZero():
+		detail = "not checked yet"
+	case state.Status != nil && state.Status.Cluster != nil && state.Status.Cluster.Status != nil:
+		detail = fmt.Sprintf(
+			"cluster %s, %d member(s), checked <t:%d:R>",
+			*state.Status.Cluster.Status,
+			len(state.Status.Cluster.Peers),
+			state.Checked.Unix(),
+		)
+	default:
+		detail = fmt.Sprintf("checked <t:%d:R>", state.Checked.Unix())
+	}
+
+	return fmt.Sprintf("%s %s — %s", icon, state.URL, detail)
+}
+
+func (b *Bot) instanceListFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	channelDefault := b.channelInstance(h.ChannelID)
+
+	var lines []string
+	for i, al := range b.instances.List() {
+		var notes []string
+
+		if i == 0 {
+			notes = append(notes, "default")
+		}
+
+		if al == channelDefault {
+			notes = append(notes, "channel default")
+		}
+
+		if retryAt, open := al.CircuitOpen(); open {
+			notes = append(notes, fmt.Sprintf("unreachable, retrying <t:%d:R>", retryAt.Unix()))
+		}
+
+		line := fmt.Sprintf("**`%s`**", al.Name)
+		if len(notes) > 0 {
+			line += fmt.Sprintf(" (%s)", strings.Join(notes, ", "))
+		}
+
+		lines = append(lines, line)
+
+		for _, state := range al.Peers() {
+			lines = append(lines, peerStateLine(state))
+		}
+	}
+
+	err := s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Flags: disgord.MessageFlagEphemeral,
+			Embeds: []*disgord.Embed{{
+				Type:        disgord.EmbedTypeRich,
+				Color:       colorInfo,
+				Title:       "Alertmanager instances",
+				Description: truncate(strings.Join(lines, "\n"), maxEmbedDesc),
+			}},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+func (b *Bot) instanceSetDefaultFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	name, _ := optionsHasChild[string](h.Data.Options, "instance")
+
+	al, ok := b.instances.Get(name)
+	if !ok {
+		b.responseError(s, h, "Invalid Alertmanager instance", fmt.Errorf("Unknown Alertmanager instance %q.", name)) //nolint:revive,stylecheck
+		return
+	}
+
+	err := b.store.SetChannelSettings(b.ctx, &store.ChannelSettings{
+		ChannelID: h.ChannelID,
+		Instance:  al.Name,
+	})
+	if err != nil {
+		b.responseError(s, h, "An error occurred while saving channel settings", err)
+		return
+	}
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Embeds: []*disgord.Embed{{
+				Type:  disgord.EmbedTypeRich,
+				Color: colorSuccess,
+				Title: "Default instance updated",
+				Description: fmt.Sprintf(
+					"<@%d> set the default Alertmanager instance for <#%d> to [%s](%s).",
+					h.Member.User.ID, h.ChannelID, al.Name, al.URL(),
+				),
+			}},
+			AllowedMentions: &disgord.AllowedMentions{Parse: []string{}},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+const (
+	// maxStatusPeers is the maximum number of cluster peers listed in the status.
+	maxStatusPeers      = 10
+	shortRevisionLength = 12
+)
+
+func (b *Bot) alertmanagerStatusFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	al := b.instance(h)
+
+	params := &general.GetStatusParams{}
+	params.SetContext(ctx)
+	params.SetTimeout(httpRequestTimeout)
+
+	resp, err := al.General.GetStatus(params, al.HandleAuth)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching Alertmanager status", err)
+		return
+	}
+
+	status := resp.Payload
+	fields := []*disgord.EmbedField{}
+
+	if v := status.VersionInfo; v != nil {
+		revision := *v.Revision
+		if len(revision) > shortRevisionLength {
+			revision = revision[:shortRevisionLength]
+		}
+
+		fields = append(fields, &disgord.EmbedField{
+			Name:   ":label: Version",
+			Value:  fmt.Sprintf("`%s` (revision `%s`, %s)", *v.Version, revision, *v.GoVersion),
+			Inline: true,
+		})
+	}
+
+	if status.Uptime != nil {
+		fields = append(fields, &disgord.EmbedField{
+			Name:   ":watch: Started",
+			Value:  fmt.Sprintf("<t:%d:R>", time.Time(*status.Uptime).Unix()),
+			Inline: true,
+		})
+	}
+
+	if cluster := status.Cluster; cluster != nil && cluster.Status != nil {
+		value := fmt.Sprintf("`%s`", *cluster.Status)
+		if cluster.Name != "" {
+			value = fmt.Sprintf("`%s` (%s)", cluster.Name, *cluster.Status)
+		}
+
+		fields = append(fields, &disgord.EmbedField{
+			Name:   ":link: Cluster",
+			Value:  value,
+			Inline: true,
+		})
+
+		if len(cluster.Peers) > 0 {
+			peers := make([]string, 0, len(cluster.Peers))
+			for i, p := range cluster.Peers {
+				if i >= maxStatusPeers {
+					peers = append(peers, fmt.Sprintf("... and %d more", len(cluster.Peers)-i))
+					break
+				}
+
+				peers = append(peers, fmt.Sprintf("`%s` %s", *p.Name, *p.Address))
+			}
+
+			fields = append(fields, &disgord.EmbedField{
+				Name:  fmt.Sprintf(":busts_in_silhouette: Cluster peers (%d)", len(cluster.Peers)),
+				Value: truncate(strings.Join(peers, "\n"), 1024), //nolint:gomnd
+			})
+		}
+	}
+
+	if status.Config != nil && status.Config.Original != nil {
+		fields = append(fields, configSummaryFields(*status.Config.Original)...)
+	}
+
+	if states := al.Peers(); len(states) > 1 {
+		lines := make([]string, 0, len(states))
+		for _, state := range states {
+			lines = append(lines, peerStateLine(state))
+		}
+
+		fields = append(fields, &disgord.EmbedField{
+			Name:  ":stethoscope: Peer health (as seen by the bot)",
+			Value: truncate(strings.Join(lines, "\n"), 1024), //nolint:gomnd
+		})
+	}
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Flags: disgord.MessageFlagEphemeral,
+			Embeds: []*disgord.Embed{{
+				Type:   disgord.EmbedTypeRich,
+				Color:  colorInfo,
+				Title:  fmt.Sprintf("Alertmanager status: %s", al.Name),
+				URL:    al.URL() + "/#/status",
+				Fields: fields,
+			}},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+// configSummaryFields summarizes the loaded Alertmanager configuration.
+func configSummaryFields(original string) []*disgord.EmbedField {
+	config, err := alertmanager.ParseConfig(original)
+	if err != nil {
+		return []*disgord.EmbedField{{
+			Name:  ":gear: Configuration",
+			Value: truncate(fmt.Sprintf("Unable to summarize configuration: %v", err), 1024), //nolint:gomnd
+		}}
+	}
+
+	receivers := make([]string, 0, len(config.Receivers))
+	for _, receiver := range config.Receivers {
+		line := fmt.Sprintf("`%s`", receiver.Name)
+		if len(receiver.Integrations) > 0 {
+			line += fmt.Sprintf(" (%s)", strings.Join(receiver.Integrations, ", "))
+		}
+
+		receivers = append(receivers, line)
+	}
+
+	if len(receivers) == 0 {
+		receivers = append(receivers, "(none)")
+	}
+
+	return []*disgord.EmbedField{
+		{
+			Name: ":gear: Configuration",
+			Value: fmt.Sprintf(
+				"%d route(s), %d level(s) deep, %d inhibit rule(s)",
+				config.Route.Count(), config.Route.Depth(), len(config.InhibitRules),
+			),
+		},
+		{
+			Name:  fmt.Sprintf(":incoming_envelope: Receivers (%d)", len(config.Receivers)),
+			Value: truncate(strings.Join(receivers, "\n"), 1024), //nolint:gomnd
+		},
+	}
+}
+
+// alertmanagerConfig fetches and parses the configuration currently loaded by
+// the instance.
+func (b *Bot) alertmanagerConfig(ctx context.Context, al *alertmanager.Client) (*alertmanager.Config, error) {
+	params := &general.GetStatusParams{}
+	params.SetContext(ctx)
+	params.SetTimeout(httpRequestTimeout)
+
+	resp, err := al.General.GetStatus(params, al.HandleAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Payload.Config == nil || resp.Payload.Config.Original == nil {
+		return nil, errors.New("Alertmanager did not report its configuration.") //nolint:revive,stylecheck
+	}
+
+	return alertmanager.ParseConfig(*resp.Payload.Config.Original)
+}
+
+// routeLine returns a single line summary of a route, e.g.:
+//
+//	db-pager {severity="critical"} (continue)
+func routeLine(route *alertmanager.Route) string {
+	line := route.Receiver
+
+	if matchers := route.MatcherStrings(); len(matchers) > 0 {
+		line += " {" + strings.Join(matchers, ", ") + "}"
+	}
+
+	if route.Continue {
+		line += " (continue)"
+	}
+
+	return line
+}
+
+// routeTree renders the routing tree as a code block, marking any of the
+// provided routes. Routes which don't fit are omitted.
+func routeTree(root *alertmanager.Route, marked []*alertmanager.Route, maxLength int) string {
+	var lines []string
+
+	var walk func(route *alertmanager.Route, indent, branch, childIndent string)
+	walk = func(route *alertmanager.Route, indent, branch, childIndent string) {
+		line := indent + branch + routeLine(route)
+		if slices.Contains(marked, route) {
+			line += "  ◀"
+		}
+
+		lines = append(lines, line)
+
+		for i, child := range route.Routes {
+			if i == len(route.Routes)-1 {
+				walk(child, indent+childIndent, "└── ", "    ")
+			} else {
+				walk(child, indent+childIndent, "├── ", "│   ")
+			}
+		}
+	}
+
+	walk(root, "", "", "")
+
+	// Account for the code block and the omitted line.
+	maxLength -= 50
+
+	var out strings.Builder
+	for i, line := range lines {
+		if out.Len()+len(line)+1 > maxLength {
+			fmt.Fprintf(&out, "... and %d more route(s)\n", len(lines)-i)
+			break
+		}
+
+		out.WriteString(line + "\n")
+	}
+
+	return "```\n" + out.String() + "```"
+}
+
+func (b *Bot) alertmanagerRoutesFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	al := b.instance(h)
+
+	config, err := b.alertmanagerConfig(ctx, al)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching Alertmanager configuration", err)
+		return
+	}
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Flags: disgord.MessageFlagEphemeral,
+			Embeds: []*disgord.Embed{{
+				Type:        disgord.EmbedTypeRich,
+				Color:       colorInfo,
+				Title:       fmt.Sprintf("Alertmanager routes: %s", al.Name),
+				URL:         al.URL() + "/#/status",
+				Description: routeTree(config.Route, nil, maxEmbedDesc),
+				Footer: &disgord.EmbedFooter{
+					Text: fmt.Sprintf(
+						"%d route(s), %d level(s) deep — use /alertmanager route-test to test a label set",
+						config.Route.Count(), config.Route.Depth(),
+					),
+				},
+			}},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+// matchersToLabels converts matchers to a label set, for inputs which describe
+// an alert rather than a filter.
+func matchersToLabels(matchers []*almodels.Matcher) (map[string]string, error) {
+	labels := make(map[string]string, len(matchers))
+	for _, m := range matchers {
+		if !*m.IsEqual || *m.IsRegex {
+			return nil, fmt.Errorf(
+				"Only equality matchers can be used for alert labels (e.g. `%s=\"value\"`).", *m.Name, //nolint:revive,stylecheck
+			)
+		}
+
+		labels[*m.Name] = *m.Value
+	}
+
+	return labels, nil
+}
+
+func (b *Bot) alertmanagerRouteTestFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	al := b.instance(h)
+
+	input, _ := optionsHasChild[string](h.Data.Options, "labels")
+
+	matchers, err := alertmanager.ParseLabels(input, false)
+	if err != nil {
+		b.responseError(s, h, "Invalid labels provided", err)
+		return
+	}
+
+	labels, err := matchersToLabels(matchers)
+	if err != nil {
+		b.responseError(s, h, "Invalid labels provided", err)
+		return
+	}
+
+	config, err := b.alertmanagerConfig(ctx, al)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching Alertmanager configuration", err)
+		return
+	}
+
+	matched := config.Route.Matching(labels)
+
+	receivers := make([]string, 0, len(matched))
+	paths := make([]string, 0, len(matched))
+
+	for _, route := range matched {
+		if !slices.Contains(receivers, route.Receiver) {
+			receivers = append(receivers, route.Receiver)
+		}
+
+		path := route.Path()
+		steps := make([]string, 0, len(path))
+		for _, step := range path {
+			steps = append(steps, routeLine(step))
+		}
+
+		paths = append(paths, "`"+strings.Join(steps, "` → `")+"`")
+	}
+
+	for i := range receivers {
+		receivers[i] = "`" + receivers[i] + "`"
+	}
+
+	embed := &disgord.Embed{
+		Type:  disgord.EmbedTypeRich,
+		Color: colorInfo,
+		Title: fmt.Sprintf("Alertmanager route test: %s", al.Name),
+		URL:   al.URL() + "/#/status",
+		Fields: []*disgord.EmbedField{
+			{
+				Name:  ":label: Labels",
+				Value: truncate("`"+strings.Join(alertmanager.MatcherToString(matchers, false), "`, `")+"`", 1024), //nolint:gomnd
+			},
+			{
+				Name:  fmt.Sprintf(":incoming_envelope: Receivers (%d)", len(receivers)),
+				Value: truncate(strings.Join(receivers, ", "), 1024), //nolint:gomnd
+			},
+			{
+				Name:  fmt.Sprintf(":twisted_rightwards_arrows: Matched routes (%d)", len(paths)),
+				Value: truncate(strings.Join(paths, "\n"), 1024), //nolint:gomnd
+			},
+		},
+	}
+
+	// The tree gets whatever is left of the message length limit after the fields.
+	treeLength := maxMessageEmbedsLength - embedsLength([]*disgord.Embed{embed})
+	if treeLength > maxEmbedDesc {
+		treeLength = maxEmbedDesc
+	}
+
+	embed.Description = routeTree(config.Route, matched, treeLength)
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Flags:  disgord.MessageFlagEphemeral,
+			Embeds: []*disgord.Embed{embed},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
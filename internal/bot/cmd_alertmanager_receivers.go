@@ -0,0 +1,329 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/go-openapi/strfmt"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/prometheus/alertmanager/api/v2/client/alert"
+	"github.com/prometheus/alertmanager/api/v2/client/receiver"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// testAlertName is the alertname of test alerts, unless overridden.
+	testAlertName = "DiscordAlertmanagerTest"
+	// testAlertLabel is a unique label added to test alerts, so they can be found
+	// again.
+	testAlertLabel = "test_id"
+	// testAlertDuration is how long test alerts fire for, before they resolve on
+	// their own.
+	testAlertDuration = 5 * time.Minute
+	// testAlertWait is how long to wait for test alerts to show up.
+	testAlertWait         = 10 * time.Second
+	testAlertPollInterval = 1 * time.Second
+
+	// receiversTTL is how long receivers are cached for autocomplete, which
+	// otherwise fetches them on every keystroke.
+	receiversTTL = 30 * time.Second
+)
+
+// getReceivers returns the names of the receivers known to the instance.
+func (b *Bot) getReceivers(ctx context.Context, al *alertmanager.Client) ([]string, error) {
+	params := &receiver.GetReceiversParams{}
+	params.SetContext(ctx)
+	params.SetTimeout(httpRequestTimeout)
+
+	resp, err := al.Receiver.GetReceivers(params, al.HandleAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(resp.Payload))
+	for _, r := range resp.Payload {
+		if r.Name != nil {
+			names = append(names, *r.Name)
+		}
+	}
+
+	return names, nil
+}
+
+// cachedReceivers is the same as getReceivers, but caches the receivers for a
+// short time, per instance.
+func (b *Bot) cachedReceivers(ctx context.Context, al *alertmanager.Client) ([]string, error) {
+	if names, ok := b.receivers.Get(al.Name); ok {
+		return names, nil
+	}
+
+	names, err := b.getReceivers(ctx, al)
+	if err != nil {
+		return nil, err
+	}
+
+	b.receivers.SetKey(al.Name, names)
+	return names, nil
+}
+
+func (b *Bot) receiverListFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	al := b.instance(h)
+
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	names, err := b.getReceivers(ctx, al)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching receivers", err)
+		return
+	}
+
+	// The configuration is only used to add detail, so it's fine if it can't be
+	// fetched or parsed.
+	config, err := b.alertmanagerConfig(ctx, al)
+	if err != nil {
+		b.logger.WithError(err).Warn("failed to fetch alertmanager configuration")
+	}
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		line := fmt.Sprintf("`%s`", name)
+
+		if config != nil {
+			for _, r := range config.Receivers {
+				if r.Name == name && len(r.Integrations) > 0 {
+					line += fmt.Sprintf(" (%s)", strings.Join(r.Integrations, ", "))
+				}
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "No receivers are configured.")
+	}
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Flags: disgord.MessageFlagEphemeral,
+			Embeds: []*disgord.Embed{{
+				Type:        disgord.EmbedTypeRich,
+				Color:       colorInfo,
+				Title:       fmt.Sprintf("Alertmanager receivers: %s (%d)", al.Name, len(names)),
+				URL:         al.URL() + "/#/status",
+				Description: truncate(strings.Join(lines, "\n"), maxEmbedDesc),
+				Footer: &disgord.EmbedFooter{
+					Text: "use /alertmanager receiver-test to send a test notification",
+				},
+			}},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+// receiverTestFromCommand posts a synthetic alert with labels that route it to
+// the requested receiver, and reports whether Alertmanager accepted and routed it
+// as expected.
+func (b *Bot) receiverTestFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	al := b.instance(h)
+
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	name, _ := optionsHasChild[string](h.Data.Options, "receiver")
+
+	labels := map[string]string{"alertname": testAlertName}
+
+	if input, _ := optionsHasChild[string](h.Data.Options, "labels"); input != "" {
+		matchers, err := alertmanager.ParseLabels(input, false)
+		if err != nil {
+			b.responseError(s, h, "Invalid labels provided", err)
+			return
+		}
+
+		extra, err := matchersToLabels(matchers)
+		if err != nil {
+			b.responseError(s, h, "Invalid labels provided", err)
+			return
+		}
+
+		maps.Copy(labels, extra)
+	}
+
+	config, err := b.alertmanagerConfig(ctx, al)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching Alertmanager configuration", err)
+		return
+	}
+
+	if !slices.ContainsFunc(config.Receivers, func(r *alertmanager.Receiver) bool { return r.Name == name }) {
+		b.responseError(s, h, "Invalid receiver", fmt.Errorf("Receiver `%s` is not configured.", name)) //nolint:revive,stylecheck
+		return
+	}
+
+	labels, ok := config.Route.LabelsFor(name, labels)
+	if !ok {
+		b.responseError(s, h, "Unable to route test alert", fmt.Errorf(
+			"Unable to determine labels which would route an alert to `%s`. Please provide them with the `labels` option.", name, //nolint:revive,stylecheck
+		))
+		return
+	}
+
+	if !b.authorizeMatchers(s, h, actionAlert, alertmanager.LabelsToMatchers(labels)) {
+		return
+	}
+
+	buf := make([]byte, 8) //nolint:gomnd
+	_, _ = rand.Read(buf)
+	labels[testAlertLabel] = hex.EncodeToString(buf)
+
+	// Waiting for the alert to show up can take longer than Discord allows for
+	// the initial response.
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+		return
+	}
+
+	now := time.Now()
+
+	params := &alert.PostAlertsParams{
+		Alerts: almodels.PostableAlerts{{
+			Alert: almodels.Alert{
+				Labels: labels,
+			},
+			Annotations: almodels.LabelSet{
+				"summary": fmt.Sprintf("Test notification for receiver %s", name),
+				"description": fmt.Sprintf(
+					"Sent from Discord by %s to validate routing. It resolves on its own after %s.",
+					h.Member.User.Username, testAlertDuration,
+				),
+			},
+			StartsAt: strfmt.DateTime(now),
+			EndsAt:   strfmt.DateTime(now.Add(testAlertDuration)),
+		}},
+	}
+	params.SetContext(b.ctx)
+	params.SetTimeout(httpRequestTimeout)
+
+	if _, err = al.Alert.PostAlerts(params, al.HandleAuth); err != nil {
+		b.deferredResponseError(s, h, "An error occurred while sending test alert", err)
+		return
+	}
+
+	found, err := b.waitForTestAlert(al, labels[testAlertLabel])
+	if err != nil {
+		b.deferredResponseError(s, h, "An error occurred while checking for test alert", err)
+		return
+	}
+
+	var receivers []string
+
+	embed := &disgord.Embed{
+		Type:  disgord.EmbedTypeRich,
+		Title: fmt.Sprintf("Test notification: %s", name),
+		URL:   al.URL() + "/#/alerts",
+		Fields: []*disgord.EmbedField{
+			{
+				Name:  ":label: Labels",
+				Value: truncate("`"+strings.Join(alertmanager.MatcherToString(alertmanager.LabelsToMatchers(labels), false), "`, `")+"`", 1024), //nolint:gomnd
+			},
+		},
+	}
+
+	switch {
+	case found == nil:
+		embed.Color = colorError
+		embed.Description = fmt.Sprintf(
+			"Alertmanager accepted the test alert, but it didn't show up in `/api/v2/alerts` within %s.",
+			testAlertWait,
+		)
+	default:
+		for _, r := range found.Receivers {
+			receivers = append(receivers, "`"+*r.Name+"`")
+		}
+
+		if slices.Contains(receivers, "`"+name+"`") {
+			embed.Color = colorSuccess
+			embed.Description = fmt.Sprintf(
+				"The test alert was routed to `%s`, and should be delivered once its group wait has passed.",
+				name,
+			)
+		} else {
+			embed.Color = colorWarning
+			embed.Description = fmt.Sprintf("The test alert was **not** routed to `%s`.", name)
+		}
+
+		if found.Status != nil && found.Status.State != nil && *found.Status.State != almodels.AlertStatusStateActive {
+			embed.Description += fmt.Sprintf(" Note that the alert is currently **%s**, so no notification will be sent.", *found.Status.State)
+		}
+
+		if len(receivers) == 0 {
+			receivers = append(receivers, "(none)")
+		}
+
+		embed.Fields = append(embed.Fields, &disgord.EmbedField{
+			Name:  ":incoming_envelope: Routed to",
+			Value: truncate(strings.Join(receivers, ", "), 1024), //nolint:gomnd
+		})
+	}
+
+	if field := b.instanceField(al); field != nil {
+		embed.Fields = append(embed.Fields, field)
+	}
+
+	err = s.EditInteractionResponse(b.ctx, h, &disgord.UpdateMessage{
+		Embeds: &[]*disgord.Embed{embed},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to update interaction response")
+	}
+}
+
+// waitForTestAlert polls Alertmanager until the test alert with the provided ID
+// shows up, returning nil if it doesn't within testAlertWait.
+func (b *Bot) waitForTestAlert(al *alertmanager.Client, id string) (*almodels.GettableAlert, error) {
+	deadline := time.Now().Add(testAlertWait)
+
+	for {
+		params := &alert.GetAlertsParams{Filter: []string{fmt.Sprintf("%s=%q", testAlertLabel, id)}}
+		params.SetContext(b.ctx)
+		params.SetTimeout(httpRequestTimeout)
+
+		resp, err := al.Alert.GetAlerts(params, al.HandleAuth)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(resp.Payload) > 0 {
+			return resp.Payload[0], nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, nil
+		}
+
+		select {
+		case <-b.ctx.Done():
+			return nil, b.ctx.Err()
+		case <-time.After(testAlertPollInterval):
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+)
+
+func TestRouteTreeLength(t *testing.T) {
+	root := &alertmanager.Route{Receiver: "default"}
+	for i := 0; i < 200; i++ {
+		root.Routes = append(root.Routes, &alertmanager.Route{
+			Receiver: fmt.Sprintf("team-%d", i),
+			Routes:   []*alertmanager.Route{{Receiver: fmt.Sprintf("team-%d-pager", i)}},
+		})
+	}
+
+	for _, maxLength := range []int{500, 2000, maxEmbedDesc} {
+		tree := routeTree(root, nil, maxLength)
+
+		if got := len([]rune(tree)); got > maxLength {
+			t.Errorf("max length %d: got tree of length %d", maxLength, got)
+		}
+
+		if !strings.Contains(tree, "more route(s)") {
+			t.Errorf("max length %d: expected omitted routes to be mentioned", maxLength)
+		}
+	}
+
+	if tree := routeTree(&alertmanager.Route{Receiver: "default"}, nil, 500); tree != "```\ndefault\n```" {
+		t.Errorf("got %q for a single route", tree)
+	}
+}
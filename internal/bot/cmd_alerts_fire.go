@@ -0,0 +1,386 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/go-openapi/strfmt"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/prometheus/alertmanager/api/v2/client/alert"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/maps"
+)
+
+const (
+	defaultAlertDuration = 1 * time.Hour
+
+	// alertFiredByAnnotation is added to alerts fired through the bot. Only those
+	// alerts can be resolved through the bot, as any other alert would just be
+	// sent again by whatever fired it (e.g. Prometheus).
+	alertFiredByAnnotation = "fired_by"
+)
+
+type fireConfig struct {
+	labels      string
+	annotations string
+	duration    string
+
+	labelsParsed      map[string]string
+	annotationsParsed map[string]string
+	durationParsed    time.Duration
+}
+
+func (c *fireConfig) validate() error {
+	if c.labels == "" {
+		return errors.New("labels are required")
+	}
+
+	matchers, err := alertmanager.ParseLabels(c.labels, false)
+	if err != nil {
+		return fmt.Errorf("invalid labels provided: %w", err)
+	}
+
+	c.labelsParsed, err = matchersToLabels(matchers)
+	if err != nil {
+		return fmt.Errorf("invalid labels provided: %w", err)
+	}
+
+	c.annotationsParsed = make(map[string]string)
+
+	if c.annotations != "" {
+		matchers, err = alertmanager.ParseLabels(c.annotations, false)
+		if err != nil {
+			return fmt.Errorf("invalid annotations provided: %w", err)
+		}
+
+		c.annotationsParsed, err = matchersToLabels(matchers)
+		if err != nil {
+			return fmt.Errorf("invalid annotations provided: %w", err)
+		}
+
+		// Set by the bot, to track who fired the alert.
+		if _, ok := c.annotationsParsed[alertFiredByAnnotation]; ok {
+			return fmt.Errorf("invalid annotations provided: %q is reserved", alertFiredByAnnotation)
+		}
+	}
+
+	if c.duration == "" {
+		c.duration = defaultAlertDuration.String()
+	}
+
+	c.durationParsed, err = time.ParseDuration(strings.ToLower(c.duration))
+	if err != nil || c.durationParsed <= 0 {
+		return fmt.Errorf("invalid duration provided: %q (e.g. 30m, 4h)", c.duration)
+	}
+
+	return nil
+}
+
+func (b *Bot) alertFireFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	config := &fireConfig{}
+	config.labels, _ = optionsHasChild[string](h.Data.Options, "labels")
+	config.annotations, _ = optionsHasChild[string](h.Data.Options, "annotations")
+	config.duration, _ = optionsHasChild[string](h.Data.Options, "duration")
+
+	if config.labels == "" {
+		b.modalFire(s, h, b.customIDFor(b.instance(h), "modal-alert-fire"), config)
+		return
+	}
+
+	b.fireAlert(s, h, config)
+}
+
+func (b *Bot) alertFireFromModalCallback(s disgord.Session, h *disgord.InteractionCreate, _ string, _ []string) {
+	config := &fireConfig{}
+	config.labels, _ = componentsHasChild[string](h.Data.Components, "labels")
+	config.annotations, _ = componentsHasChild[string](h.Data.Components, "annotations")
+	config.duration, _ = componentsHasChild[string](h.Data.Components, "duration")
+
+	b.fireAlert(s, h, config)
+}
+
+// fireAlert posts an alert to Alertmanager, which is then routed the same as
+// alerts from any other source.
+func (b *Bot) fireAlert(s disgord.Session, h *disgord.InteractionCreate, config *fireConfig) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	if err := config.validate(); err != nil {
+		b.responseError(s, h, "Invalid alert provided", err)
+		return
+	}
+
+	al := b.instance(h)
+
+	if !b.authorizeMatchers(s, h, actionAlert, alertmanager.LabelsToMatchers(config.labelsParsed)) {
+		return
+	}
+
+	config.annotationsParsed[alertFiredByAnnotation] = fmt.Sprintf("<@%d> (%s)", h.Member.User.ID, h.Member.User.Username)
+
+	now := time.Now()
+
+	params := &alert.PostAlertsParams{
+		Alerts: almodels.PostableAlerts{{
+			Alert: almodels.Alert{
+				Labels: config.labelsParsed,
+			},
+			Annotations: config.annotationsParsed,
+			StartsAt:    strfmt.DateTime(now),
+			EndsAt:      strfmt.DateTime(now.Add(config.durationParsed)),
+		}},
+	}
+	params.SetContext(ctx)
+	params.SetTimeout(httpRequestTimeout)
+
+	if _, err := al.Alert.PostAlerts(params, al.HandleAuth); err != nil {
+		b.responseError(s, h, "An error occurred while firing alert", err)
+		return
+	}
+
+	// Refetch to get the fingerprint and status info.
+	var fired *almodels.GettableAlert
+
+	alerts, err := b.matchingAlerts(ctx, al, alertmanager.LabelsToMatchers(config.labelsParsed))
+	if err != nil {
+		b.logger.WithError(err).Warn("failed to fetch fired alert")
+	}
+
+	for _, alertEntry := range alerts {
+		if maps.Equal(alertEntry.Labels, config.labelsParsed) {
+			fired = alertEntry
+			break
+		}
+	}
+
+	var embed *disgord.Embed
+	var components []*disgord.MessageComponent
+
+	if fired != nil {
+		embed = b.alertEmbed(al, fired)
+
+		components = []*disgord.MessageComponent{{
+			Type: disgord.MessageComponentActionRow,
+			Components: []*disgord.MessageComponent{{
+				Type:     disgord.MessageComponentButton,
+				Style:    disgord.Success,
+				Label:    "Resolve",
+				CustomID: b.customIDFor(al, "alert-resolve", *fired.Fingerprint),
+			}},
+		}}
+	} else {
+		embed = &disgord.Embed{
+			Type:        disgord.EmbedTypeRich,
+			Color:       colorError,
+			Title:       alertTitle(alertmanager.StatusFiring, config.labelsParsed),
+			Description: alertDescription(alertmanager.StatusFiring, config.labelsParsed, config.annotationsParsed, ""),
+		}
+
+		if field := b.instanceField(al); field != nil {
+			embed.Fields = append(embed.Fields, field)
+		}
+	}
+
+	embed.Fields = append([]*disgord.EmbedField{{
+		Name:   ":alarm_clock: Resolves",
+		Value:  fmt.Sprintf("<t:%d:R>", now.Add(config.durationParsed).Unix()),
+		Inline: true,
+	}}, embed.Fields...)
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Content:         fmt.Sprintf("<@%d> fired an alert:", h.Member.User.ID),
+			AllowedMentions: &disgord.AllowedMentions{Parse: []string{}},
+			Embeds:          []*disgord.Embed{embed},
+			Components:      components,
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+func (b *Bot) modalFire(s disgord.Session, h *disgord.InteractionCreate, customID string, config *fireConfig) {
+	if config.duration == "" {
+		config.duration = defaultAlertDuration.String()
+	}
+
+	err := s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackModal,
+		Data: &disgord.CreateInteractionResponseData{
+			Title:    "Fire alert",
+			Flags:    disgord.MessageFlagEphemeral,
+			CustomID: customID,
+			Components: []*disgord.MessageComponent{
+				{
+					Type: disgord.MessageComponentActionRow,
+					Components: []*disgord.MessageComponent{{
+						Type:        disgord.MessageComponentTextInput,
+						Style:       disgord.TextInputStyleParagraph,
+						Required:    true,
+						CustomID:    "labels",
+						Label:       "Alert labels (multiline/comma-separated)",
+						Placeholder: "alertname=\"CustomerReportedOutage\"\nseverity=\"critical\"\nteam=\"web\"",
+						Value:       config.labels,
+					}},
+				},
+				{
+					Type: disgord.MessageComponentActionRow,
+					Components: []*disgord.MessageComponent{{
+						Type:        disgord.MessageComponentTextInput,
+						Style:       disgord.TextInputStyleParagraph,
+						Required:    false,
+						CustomID:    "annotations",
+						Label:       "Alert annotations (multiline/comma-separated)",
+						Placeholder: "summary=\"Customers are unable to log in\"\nticket=\"INC-1234\"",
+						Value:       config.annotations,
+					}},
+				},
+				{
+					Type: disgord.MessageComponentActionRow,
+					Components: []*disgord.MessageComponent{{
+						Type:        disgord.MessageComponentTextInput,
+						Style:       disgord.TextInputStyleShort,
+						Required:    true,
+						CustomID:    "duration",
+						Label:       "Resolve after (e.g. 30m, 4h)",
+						Placeholder: "30m, 4h, etc",
+						Value:       config.duration,
+					}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+func (b *Bot) alertResolveFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	filter, _ := optionsHasChild[string](h.Data.Options, "filter")
+
+	matchers, err := alertmanager.ParseLabels(filter, true)
+	if err != nil {
+		b.responseError(s, h, "Invalid filter provided", err)
+		return
+	}
+
+	al := b.instance(h)
+
+	alerts, err := b.matchingAlerts(ctx, al, matchers)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching alerts", err)
+		return
+	}
+
+	b.resolveAlerts(s, h, al, alerts)
+}
+
+func (b *Bot) alertResolveFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	if len(args) < 1 {
+		return
+	}
+
+	al := b.instance(h)
+
+	alerts, err := b.matchingAlerts(ctx, al, nil)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching alerts", err)
+		return
+	}
+
+	for _, alertEntry := range alerts {
+		if *alertEntry.Fingerprint == args[0] {
+			b.resolveAlerts(s, h, al, []*almodels.GettableAlert{alertEntry})
+			return
+		}
+	}
+
+	b.responseError(s, h, "Alert not found", errors.New("The alert no longer exists, it may have already been resolved.")) //nolint:revive,stylecheck
+}
+
+// resolveAlerts resolves the provided alerts which were fired through the bot, by
+// sending them again with an end time of now.
+func (b *Bot) resolveAlerts(s disgord.Session, h *disgord.InteractionCreate, al *alertmanager.Client, alerts []*almodels.GettableAlert) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	now := strfmt.DateTime(time.Now())
+
+	var postable almodels.PostableAlerts
+	var lines []string
+
+	for _, alertEntry := range alerts {
+		if _, ok := alertEntry.Annotations[alertFiredByAnnotation]; !ok {
+			continue
+		}
+
+		if !b.authorizeMatchers(s, h, actionAlert, alertmanager.LabelsToMatchers(alertEntry.Labels)) {
+			return
+		}
+
+		postable = append(postable, &almodels.PostableAlert{
+			Alert:       alertEntry.Alert,
+			Annotations: alertEntry.Annotations,
+			StartsAt:    *alertEntry.StartsAt,
+			EndsAt:      now,
+		})
+
+		lines = append(lines, fmt.Sprintf(
+			"`%s`",
+			strings.Join(alertmanager.MatcherToString(alertmanager.LabelsToMatchers(alertEntry.Labels), false), ", "),
+		))
+	}
+
+	if len(postable) == 0 {
+		b.responseError(s, h, "No matching alerts", errors.New( //nolint:revive,stylecheck
+			"No matching alerts fired through `/alerts fire` were found. Other alerts are resolved by whatever fired them.",
+		))
+		return
+	}
+
+	params := &alert.PostAlertsParams{Alerts: postable}
+	params.SetContext(ctx)
+	params.SetTimeout(httpRequestTimeout)
+
+	if _, err := al.Alert.PostAlerts(params, al.HandleAuth); err != nil {
+		b.responseError(s, h, "An error occurred while resolving alerts", err)
+		return
+	}
+
+	embed := &disgord.Embed{
+		Type:        disgord.EmbedTypeRich,
+		Color:       colorSuccess,
+		Title:       fmt.Sprintf("Resolved %d alert(s)", len(postable)),
+		Description: truncate(fmt.Sprintf("<@%d> resolved:\n", h.Member.User.ID)+strings.Join(lines, "\n"), maxEmbedDesc),
+	}
+
+	if field := b.instanceField(al); field != nil {
+		embed.Fields = append(embed.Fields, field)
+	}
+
+	err := s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			AllowedMentions: &disgord.AllowedMentions{Parse: []string{}},
+			Embeds:          []*disgord.Embed{embed},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import "testing"
+
+func TestFireConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *fireConfig
+		wantErr bool
+	}{
+		{name: "labels-only", config: &fireConfig{labels: `alertname="Test"`}},
+		{name: "annotations", config: &fireConfig{labels: `alertname="Test"`, annotations: `summary="testing"`}},
+		{name: "no-labels", config: &fireConfig{annotations: `summary="testing"`}, wantErr: true},
+		{name: "fired-by", config: &fireConfig{labels: `alertname="Test"`, annotations: `fired_by="someone else"`}, wantErr: true},
+		{name: "invalid-duration", config: &fireConfig{labels: `alertname="Test"`, duration: "-1h"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
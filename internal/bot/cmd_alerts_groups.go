@@ -0,0 +1,260 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/prometheus/alertmanager/api/v2/client/alertgroup"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+const maxRowButtons = 5
+
+// alertGroupID returns a short, stable identifier for an alert group, based on its
+// receiver and labels. Alertmanager doesn't expose group keys via the API, and
+// custom IDs are limited to 100 characters, so this is used to find the group
+// again when a button is clicked.
+func alertGroupID(group *almodels.AlertGroup) string {
+	h := fnv.New64a()
+
+	if group.Receiver != nil && group.Receiver.Name != nil {
+		_, _ = h.Write([]byte(*group.Receiver.Name))
+	}
+
+	names := maps.Keys(group.Labels)
+	slices.Sort(names)
+
+	for _, name := range names {
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(name))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(group.Labels[name]))
+	}
+
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+func (b *Bot) getAlertGroups(ctx context.Context, al *alertmanager.Client, filter, receiver string) ([]*almodels.AlertGroup, error) {
+	params := &alertgroup.GetAlertGroupsParams{}
+	params.SetContext(ctx)
+	params.SetTimeout(httpRequestTimeout)
+
+	if filter != "" {
+		matchers, err := alertmanager.ParseLabels(filter, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter provided: %w", err)
+		}
+
+		params.SetFilter(alertmanager.MatcherToString(matchers, false))
+	}
+
+	if receiver != "" {
+		params.SetReceiver(&receiver)
+	}
+
+	groups, err := al.Alertgroup.GetAlertGroups(params, al.HandleAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	return groups.Payload, nil
+}
+
+func (b *Bot) alertGroupsFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	filter, _ := optionsHasChild[string](h.Data.Options, "filter")
+	receiver, _ := optionsHasChild[string](h.Data.Options, "receiver")
+	al := b.instance(h)
+
+	groups, err := b.getAlertGroups(ctx, al, filter, receiver)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching alert groups", err)
+		return
+	}
+
+	var embeds []*disgord.Embed
+
+	for i, group := range groups {
+		if i >= maxMessageEmbeds {
+			break
+		}
+
+		embed := alertGroupEmbed(i+1, group)
+		if field := b.instanceField(al); field != nil {
+			embed.Fields = append(embed.Fields, field)
+		}
+
+		embeds = append(embeds, embed)
+	}
+
+	embeds = fitEmbeds(embeds, len(groups)-len(embeds), func(omitted int) *disgord.Embed {
+		return &disgord.Embed{
+			Type:        disgord.EmbedTypeRich,
+			Color:       colorWarning,
+			Title:       fmt.Sprintf("%d more group(s) not shown", omitted),
+			Description: "Use the `filter` or `receiver` options to narrow down results.",
+		}
+	})
+
+	shown := len(embeds)
+	if shown < len(groups) {
+		shown-- // Summary of the omitted groups.
+	}
+
+	var buttons []*disgord.MessageComponent
+
+	for i, group := range groups[:shown] {
+		// Groups without any labels (e.g. "group_by: [...]" is empty) can't be
+		// turned into a useful silence.
+		if len(group.Labels) > 0 {
+			buttons = append(buttons, &disgord.MessageComponent{
+				Type:     disgord.MessageComponentButton,
+				Style:    disgord.Secondary,
+				Label:    fmt.Sprintf("Silence group #%d", i+1),
+				CustomID: b.customIDFor(al, "alert-group-silence", alertGroupID(group)),
+			})
+		}
+	}
+
+	if len(embeds) == 0 {
+		embeds = append(embeds, &disgord.Embed{
+			Type:  disgord.EmbedTypeRich,
+			Color: colorInfo,
+			Title: "No matching alert groups",
+		})
+	}
+
+	var components []*disgord.MessageComponent
+	for i := 0; i < len(buttons); i += maxRowButtons {
+		end := i + maxRowButtons
+		if end > len(buttons) {
+			end = len(buttons)
+		}
+
+		components = append(components, &disgord.MessageComponent{
+			Type:       disgord.MessageComponentActionRow,
+			Components: buttons[i:end],
+		})
+	}
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Flags:      disgord.MessageFlagEphemeral,
+			Embeds:     embeds,
+			Components: components,
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+func alertGroupEmbed(index int, group *almodels.AlertGroup) *disgord.Embed {
+	var receiver string
+	if group.Receiver != nil && group.Receiver.Name != nil {
+		receiver = *group.Receiver.Name
+	}
+
+	states := map[string]int{}
+	var started time.Time
+
+	for _, alertEntry := range group.Alerts {
+		states[*alertEntry.Status.State]++
+
+		if started.IsZero() || time.Time(*alertEntry.StartsAt).Before(started) {
+			started = time.Time(*alertEntry.StartsAt)
+		}
+	}
+
+	color := colorError
+	if states[almodels.AlertStatusStateActive] == 0 {
+		color = colorExpired
+	}
+
+	labels := strings.Join(alertmanager.MatcherToString(alertmanager.LabelsToMatchers(group.Labels), true), "\n")
+	if labels == "" {
+		labels = "(no common labels)"
+	}
+
+	fields := []*disgord.EmbedField{
+		{
+			Name:   ":incoming_envelope: Receiver",
+			Value:  fmt.Sprintf("`%s`", receiver),
+			Inline: true,
+		},
+		{
+			Name: ":bell: Alerts",
+			Value: fmt.Sprintf(
+				"%d total (%d active, %d suppressed, %d unprocessed)",
+				len(group.Alerts),
+				states[almodels.AlertStatusStateActive],
+				states[almodels.AlertStatusStateSuppressed],
+				states[almodels.AlertStatusStateUnprocessed],
+			),
+			Inline: true,
+		},
+	}
+
+	if !started.IsZero() {
+		fields = append(fields, &disgord.EmbedField{
+			Name:   ":watch: First started",
+			Value:  fmt.Sprintf("<t:%d:R>", started.Unix()),
+			Inline: true,
+		})
+	}
+
+	return &disgord.Embed{
+		Type:        disgord.EmbedTypeRich,
+		Color:       color,
+		Title:       fmt.Sprintf("Group #%d: %s", index, receiver),
+		Description: truncate("```\n"+labels+"\n```", maxEmbedDesc),
+		Fields:      fields,
+	}
+}
+
+func (b *Bot) alertGroupSilenceFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	if len(args) < 1 {
+		return
+	}
+
+	al := b.instance(h)
+
+	groups, err := b.getAlertGroups(ctx, al, "", "")
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching alert groups", err)
+		return
+	}
+
+	for _, group := range groups {
+		if alertGroupID(group) != args[0] {
+			continue
+		}
+
+		b.modalAdd(s, h, b.customIDFor(al, "modal-add"), "Silence alert group", &addConfig{
+			matchers: strings.Join(alertmanager.MatcherToString(alertmanager.LabelsToMatchers(group.Labels), false), "\n"),
+			startsAt: "now",
+			endsAt:   time.Now().Local().Add(defaultSilenceDuration).Format(time.RFC3339),
+		})
+		return
+	}
+
+	b.responseError(s, h, "Alert group not found", errors.New("The alert group no longer exists, it may have been resolved.")) //nolint:revive,stylecheck
+}
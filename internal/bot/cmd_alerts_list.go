@@ -0,0 +1,178 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/prometheus/alertmanager/api/v2/client/alert"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/slices"
+)
+
+func (b *Bot) alertListFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	filter, _ := optionsHasChild[string](h.Data.Options, "filter")
+	receiver, _ := optionsHasChild[string](h.Data.Options, "receiver")
+	al := b.instance(h)
+
+	params := &alert.GetAlertsParams{}
+	params.SetContext(ctx)
+	params.SetTimeout(httpRequestTimeout)
+
+	if filter != "" {
+		matchers, err := alertmanager.ParseLabels(filter, true)
+		if err != nil {
+			b.responseError(s, h, "Invalid filter provided", err)
+			return
+		}
+
+		params.SetFilter(alertmanager.MatcherToString(matchers, false))
+	}
+
+	if receiver != "" {
+		params.SetReceiver(&receiver)
+	}
+
+	if v, ok := optionsHasChild[bool](h.Data.Options, "active"); ok {
+		params.SetActive(&v)
+	}
+
+	if v, ok := optionsHasChild[bool](h.Data.Options, "silenced"); ok {
+		params.SetSilenced(&v)
+	}
+
+	if v, ok := optionsHasChild[bool](h.Data.Options, "inhibited"); ok {
+		params.SetInhibited(&v)
+	}
+
+	if v, ok := optionsHasChild[bool](h.Data.Options, "unprocessed"); ok {
+		params.SetUnprocessed(&v)
+	}
+
+	alerts, err := al.Alert.GetAlerts(params, al.HandleAuth)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching alerts", err)
+		return
+	}
+
+	// Newest alerts first.
+	slices.SortFunc(alerts.Payload, func(x, y *almodels.GettableAlert) bool {
+		return time.Time(*x.StartsAt).After(time.Time(*y.StartsAt))
+	})
+
+	var embeds []*disgord.Embed
+
+	for i, alertEntry := range alerts.Payload {
+		if i >= maxMessageEmbeds {
+			break
+		}
+
+		embeds = append(embeds, b.alertEmbed(al, alertEntry))
+	}
+
+	embeds = fitEmbeds(embeds, len(alerts.Payload)-len(embeds), func(omitted int) *disgord.Embed {
+		return &disgord.Embed{
+			Type:        disgord.EmbedTypeRich,
+			Color:       colorWarning,
+			Title:       fmt.Sprintf("%d more alert(s) not shown", omitted),
+			Description: "Use the `filter` option to narrow down results.",
+		}
+	})
+
+	if len(embeds) == 0 {
+		embeds = append(embeds, &disgord.Embed{
+			Type:  disgord.EmbedTypeRich,
+			Color: colorInfo,
+			Title: "No matching alerts",
+		})
+	}
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Flags:  disgord.MessageFlagEphemeral,
+			Embeds: embeds,
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+func (b *Bot) alertEmbed(al *alertmanager.Client, alertEntry *almodels.GettableAlert) *disgord.Embed {
+	state := *alertEntry.Status.State
+
+	color := colorError
+	switch state {
+	case almodels.AlertStatusStateSuppressed:
+		color = colorExpired
+	case almodels.AlertStatusStateUnprocessed:
+		color = colorWarning
+	}
+
+	fields := []*disgord.EmbedField{{
+		Name:   ":watch: Started",
+		Value:  fmt.Sprintf("<t:%d:R>", time.Time(*alertEntry.StartsAt).Unix()),
+		Inline: true,
+	}}
+
+	var receivers []string
+	for _, r := range alertEntry.Receivers {
+		receivers = append(receivers, fmt.Sprintf("`%s`", *r.Name))
+	}
+
+	if len(receivers) > 0 {
+		fields = append(fields, &disgord.EmbedField{
+			Name:   ":incoming_envelope: Receivers",
+			Value:  strings.Join(receivers, ", "),
+			Inline: true,
+		})
+	}
+
+	if len(alertEntry.Status.SilencedBy) > 0 {
+		var silences []string
+		for _, id := range alertEntry.Status.SilencedBy {
+			silences = append(silences, fmt.Sprintf("[%s](%s)", id, al.SilenceURL(id)))
+		}
+
+		fields = append(fields, &disgord.EmbedField{
+			Name:   ":mute: Silenced by",
+			Value:  strings.Join(silences, "\n"),
+			Inline: false,
+		})
+	}
+
+	if len(alertEntry.Status.InhibitedBy) > 0 {
+		fields = append(fields, &disgord.EmbedField{
+			Name:   ":no_entry: Inhibited by",
+			Value:  "`" + strings.Join(alertEntry.Status.InhibitedBy, "`, `") + "`",
+			Inline: false,
+		})
+	}
+
+	if field := b.instanceField(al); field != nil {
+		fields = append(fields, field)
+	}
+
+	return &disgord.Embed{
+		Type:        disgord.EmbedTypeRich,
+		Color:       color,
+		Title:       alertTitle(state, alertEntry.Labels),
+		Description: alertDescription(alertmanager.StatusFiring, alertEntry.Labels, alertEntry.Annotations, alertEntry.GeneratorURL.String()),
+		URL:         alertEntry.GeneratorURL.String(),
+		Fields:      fields,
+		Timestamp:   disgord.Time{Time: time.Time(*alertEntry.StartsAt)},
+		Footer: &disgord.EmbedFooter{
+			Text: fmt.Sprintf("Fingerprint: %s", *alertEntry.Fingerprint),
+		},
+	}
+}
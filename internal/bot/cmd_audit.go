@@ -0,0 +1,151 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+	"github.com/lrstanley/discord-alertmanager/internal/store"
+)
+
+const (
+	defaultAuditHistory = 10
+	maxAuditHistory     = 25
+)
+
+// auditHistoryLine returns a single line summary of an audit record.
+func (b *Bot) auditHistoryLine(record *store.AuditRecord) string {
+	short := record.SilenceID
+	if len(short) > 8 { //nolint:gomnd
+		short = short[:8]
+	}
+
+	silenceID := fmt.Sprintf("`%s`", short)
+	if al, ok := b.instances.Get(record.Instance); ok {
+		silenceID = fmt.Sprintf("[%s](%s)", short, al.SilenceURL(record.SilenceID))
+	}
+
+	line := fmt.Sprintf(
+		"<t:%d:f> <@%d> %s %s via %s",
+		record.Time.Unix(), record.UserID, record.Action, silenceID, record.Source,
+	)
+
+	if b.instances.Multiple() && record.Instance != "" {
+		line += fmt.Sprintf(" on `%s`", record.Instance)
+	}
+
+	if record.Link != "" {
+		line += fmt.Sprintf(" ([message](%s))", record.Link)
+	}
+
+	return line
+}
+
+func (b *Bot) auditHistoryFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	limit := defaultAuditHistory
+	if v, ok := optionsHasChild[float64](h.Data.Options, "limit"); ok && v > 0 {
+		limit = int(v)
+	}
+
+	records, err := b.store.AuditHistory(b.ctx, h.GuildID, limit)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching audit history", err)
+		return
+	}
+
+	embed := &disgord.Embed{
+		Type:  disgord.EmbedTypeRich,
+		Color: colorInfo,
+		Title: "Audit history",
+	}
+
+	if len(records) == 0 {
+		embed.Description = "No silence changes have been made through the bot yet."
+	} else {
+		// Only include whole lines, so links aren't cut off.
+		var sb strings.Builder
+		for _, record := range records {
+			line := b.auditHistoryLine(record) + "\n"
+			if len([]rune(sb.String()))+len([]rune(line)) > maxEmbedDesc {
+				break
+			}
+
+			sb.WriteString(line)
+		}
+
+		embed.Description = sb.String()
+	}
+
+	if b.config.Store.AuditRetention > 0 {
+		embed.Footer = &disgord.EmbedFooter{
+			Text: fmt.Sprintf("History is kept for %s", b.config.Store.AuditRetention),
+		}
+	}
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Flags:  disgord.MessageFlagEphemeral,
+			Embeds: []*disgord.Embed{embed},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+func (b *Bot) auditSetChannelFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	var channelID disgord.Snowflake
+	if v, ok := optionsHasChild[string](h.Data.Options, "channel"); ok {
+		channelID = disgord.ParseSnowflakeString(v)
+	}
+
+	settings, err := b.store.GuildSettings(b.ctx, h.GuildID)
+	if errors.Is(err, store.ErrNotFound) {
+		settings, err = &store.GuildSettings{GuildID: h.GuildID}, nil
+	}
+
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching guild settings", err)
+		return
+	}
+
+	settings.AuditChannelID = channelID
+
+	if err = b.store.SetGuildSettings(b.ctx, settings); err != nil {
+		b.responseError(s, h, "An error occurred while saving guild settings", err)
+		return
+	}
+
+	description := fmt.Sprintf("<@%d> set the audit channel to <#%d>.", h.Member.User.ID, channelID)
+	if channelID.IsZero() {
+		description = fmt.Sprintf("<@%d> reset the audit channel to the configured default.", h.Member.User.ID)
+
+		if defaultID, ok := b.auditChannels[h.GuildID]; ok {
+			description += fmt.Sprintf(" Silence changes will be posted to <#%d>.", defaultID)
+		} else {
+			description += " Silence changes will no longer be posted."
+		}
+	}
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Embeds: []*disgord.Embed{{
+				Type:        disgord.EmbedTypeRich,
+				Color:       colorSuccess,
+				Title:       "Audit channel updated",
+				Description: description,
+			}},
+			AllowedMentions: &disgord.AllowedMentions{Parse: []string{}},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
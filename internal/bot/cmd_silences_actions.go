@@ -0,0 +1,233 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/go-openapi/strfmt"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+)
+
+// silenceComponents returns the message components (buttons) which allow acting
+// on a silence directly from the embed. Expired silences can only be cloned.
+func (b *Bot) silenceComponents(al *alertmanager.Client, alertSilence *almodels.GettableSilence) []*disgord.MessageComponent {
+	id := *alertSilence.ID
+
+	buttons := []*disgord.MessageComponent{}
+
+	if *alertSilence.Status.State != "expired" {
+		buttons = append(buttons,
+			&disgord.MessageComponent{
+				Type:     disgord.MessageComponentButton,
+				Style:    disgord.Primary,
+				Label:    "Extend 1h",
+				CustomID: b.customIDFor(al, "silence-extend", id, "1h"),
+			},
+			&disgord.MessageComponent{
+				Type:     disgord.MessageComponentButton,
+				Style:    disgord.Primary,
+				Label:    "Extend 4h",
+				CustomID: b.customIDFor(al, "silence-extend", id, "4h"),
+			},
+			&disgord.MessageComponent{
+				Type:     disgord.MessageComponentButton,
+				Style:    disgord.Danger,
+				Label:    "Expire",
+				CustomID: b.customIDFor(al, "silence-expire", id),
+			},
+		)
+	}
+
+	buttons = append(buttons, &disgord.MessageComponent{
+		Type:     disgord.MessageComponentButton,
+		Style:    disgord.Secondary,
+		Label:    "Clone",
+		CustomID: b.customIDFor(al, "silence-clone", id),
+	})
+
+	if *alertSilence.Status.State != "expired" {
+		buttons = append(buttons, &disgord.MessageComponent{
+			Type:     disgord.MessageComponentButton,
+			Style:    disgord.Secondary,
+			Label:    "Edit",
+			CustomID: b.customIDFor(al, "silence-edit", id),
+		})
+	}
+
+	return []*disgord.MessageComponent{{
+		Type:       disgord.MessageComponentActionRow,
+		Components: buttons,
+	}}
+}
+
+// getSilence fetches a silence by ID from the instance, responding with an error
+// if it could not be fetched.
+func (b *Bot) getSilence(
+	s disgord.Session,
+	h *disgord.InteractionCreate,
+	al *alertmanager.Client,
+	id string,
+) (*almodels.GettableSilence, bool) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	getParams := &silence.GetSilenceParams{}
+	getParams.SetContext(ctx)
+	getParams.SetTimeout(httpRequestTimeout)
+	getParams.SetSilenceID(strfmt.UUID(id))
+	resp, err := al.Silence.GetSilence(getParams, al.HandleAuth)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching silence", err)
+		return nil, false
+	}
+
+	return resp.Payload, true
+}
+
+func (b *Bot) silenceExtendFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	if len(args) < 2 { //nolint:gomnd
+		return
+	}
+
+	extendBy, err := time.ParseDuration(args[1])
+	if err != nil {
+		b.responseError(s, h, "Invalid extension duration", err)
+		return
+	}
+
+	al := b.instance(h)
+
+	alertSilence, ok := b.getSilence(s, h, al, args[0])
+	if !ok {
+		return
+	}
+
+	_ = b.addOrUpdateSilence(s, h, &addConfig{
+		al:          al,
+		id:          args[0],
+		previous:    alertSilence,
+		skipPreview: true,
+		comment:     *alertSilence.Comment,
+		matchers:    strings.Join(alertmanager.MatcherToString(alertSilence.Matchers, false), "\n"),
+		// Keep the exact start time, so Alertmanager can update the silence in place.
+		startsAt: time.Time(*alertSilence.StartsAt).Format(time.RFC3339Nano),
+		endsAt:   time.Time(*alertSilence.EndsAt).Add(extendBy).Format(time.RFC3339Nano),
+	})
+}
+
+// silenceExpireFromButton asks the user to confirm before expiring the silence,
+// as the button sits right next to the extend buttons.
+func (b *Bot) silenceExpireFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	if len(args) < 1 {
+		return
+	}
+
+	al := b.instance(h)
+
+	alertSilence, ok := b.getSilence(s, h, al, args[0])
+	if !ok {
+		return
+	}
+
+	err := s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Flags: disgord.MessageFlagEphemeral,
+			Embeds: []*disgord.Embed{{
+				Type:  disgord.EmbedTypeRich,
+				Color: colorWarning,
+				Title: fmt.Sprintf("Expire silence: %s?", args[0]),
+				Description: "Alerts matching the following will start notifying again.\n```\n" + strings.Join(
+					alertmanager.MatcherToString(alertSilence.Matchers, true), "\n",
+				) + "\n```",
+			}},
+			Components: []*disgord.MessageComponent{{
+				Type: disgord.MessageComponentActionRow,
+				Components: []*disgord.MessageComponent{
+					{
+						Type:     disgord.MessageComponentButton,
+						Style:    disgord.Danger,
+						Label:    "Expire",
+						CustomID: b.customIDFor(al, "silence-expire-confirm", args[0]),
+					},
+					{
+						Type:     disgord.MessageComponentButton,
+						Style:    disgord.Secondary,
+						Label:    "Cancel",
+						CustomID: b.customIDFor(al, "silence-expire-cancel", args[0]),
+					},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+func (b *Bot) silenceExpireConfirmFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	if len(args) < 1 {
+		return
+	}
+
+	_ = b.silenceRemove(s, h, b.instance(h), args[0])
+}
+
+func (b *Bot) silenceExpireCancelFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, _ []string) {
+	err := s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackUpdateMessage,
+		Data: &disgord.CreateInteractionResponseData{
+			Embeds: []*disgord.Embed{{
+				Type:  disgord.EmbedTypeRich,
+				Color: colorExpired,
+				Title: "Expire cancelled",
+			}},
+			Components: []*disgord.MessageComponent{},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+func (b *Bot) silenceCloneFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	if len(args) < 1 {
+		return
+	}
+
+	al := b.instance(h)
+
+	alertSilence, ok := b.getSilence(s, h, al, args[0])
+	if !ok {
+		return
+	}
+
+	// Keep the same duration as the original silence, but start it now.
+	duration := time.Time(*alertSilence.EndsAt).Sub(time.Time(*alertSilence.StartsAt)).Round(time.Minute)
+	if duration <= 0 {
+		duration = defaultSilenceDuration
+	}
+
+	b.modalAdd(s, h, b.customIDFor(al, "modal-add"), "Clone silence", &addConfig{
+		comment:  *alertSilence.Comment,
+		matchers: strings.Join(alertmanager.MatcherToString(alertSilence.Matchers, false), "\n"),
+		startsAt: "now",
+		endsAt:   duration.String(),
+	})
+}
+
+func (b *Bot) silenceEditFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	if len(args) < 1 {
+		return
+	}
+
+	b.silenceEditModal(s, h, b.instance(h), args[0])
+}
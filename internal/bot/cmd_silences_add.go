@@ -16,6 +16,7 @@ import (
 	"github.com/go-openapi/strfmt"
 	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
 	"github.com/lrstanley/discord-alertmanager/internal/models"
+	"github.com/lrstanley/discord-alertmanager/internal/store"
 	"github.com/prometheus/alertmanager/api/v2/client/silence"
 	almodels "github.com/prometheus/alertmanager/api/v2/models"
 )
@@ -52,19 +53,35 @@ func parseSilenceTime(input string) (time.Time, error) {
 }
 
 type addConfig struct {
+	// al is the instance the silence is created/updated in, defaulting to the
+	// instance the interaction applies to.
+	al *alertmanager.Client
+
 	id string // Only used when editing.
 
+	// previous is the silence being replaced, when editing. It's fetched by id
+	// if not already provided by the caller.
+	previous *almodels.GettableSilence
+
+	// source is how the silence change was originally requested, for auditing.
+	source string
+
+	// skipPreview skips the confirmation step, for actions which don't change
+	// which alerts are matched (e.g. extending a silence).
+	skipPreview bool
+
 	comment  string
 	matchers string
 	startsAt string
 	endsAt   string
 
-	matchersParsed []*almodels.Matcher
-	startsAtParsed time.Time
-	endsAtParsed   time.Time
+	matchersParsed   []*almodels.Matcher
+	matchersCompiled alertmanager.Matchers
+	startsAtParsed   time.Time
+	endsAtParsed     time.Time
 }
 
-func (m *addConfig) validate() (err error) {
+func (m *addConfig) validate(rails models.ConfigGuardrails) (err error) {
 	if m.comment == "" {
 		return errors.New("comment is required")
 	}
@@ -78,6 +95,11 @@ func (m *addConfig) validate() (err error) {
 		return fmt.Errorf("invalid filter/matchers provided: %w", err)
 	}
 
+	m.matchersCompiled, err = alertmanager.CompileMatchers(m.matchersParsed)
+	if err != nil {
+		return fmt.Errorf("invalid filter/matchers provided: %w", err)
+	}
+
 	if m.startsAt == "" {
 		m.startsAt = "now"
 	}
@@ -96,17 +118,61 @@ func (m *addConfig) validate() (err error) {
 		return fmt.Errorf("invalid endsAt provided: %w", err)
 	}
 
-	return nil
+	return m.checkGuardrails(rails)
 }
 
+// addOrUpdateSilence validates the silence configuration, and unless skipped,
+// shows a preview of the alerts it would match, before submitting it.
 func (b *Bot) addOrUpdateSilence(s disgord.Session, h *disgord.InteractionCreate, config *addConfig) (ok bool) { //nolint:unparam
-	if err := config.validate(); err != nil {
-		b.responseError(s, h, "Invalid silence configuration provided", err)
+	if err := config.validate(b.config.Guardrails); err != nil {
+		b.responseValidationError(s, h, err)
+		return false
+	}
+
+	if config.source == "" {
+		config.source = interactionSource(h)
+	}
+
+	if config.al == nil {
+		config.al = b.instance(h)
+	}
+
+	action := actionAdd
+	if config.id != "" {
+		action = actionEdit
+
+		if config.previous == nil {
+			config.previous, ok = b.getSilence(s, h, config.al, config.id)
+			if !ok {
+				return false
+			}
+		}
+
+		// Make sure the user is also allowed to manage the silence being replaced.
+		if !b.authorizeMatchers(s, h, action, config.previous.Matchers) {
+			return false
+		}
+	}
+
+	if !b.authorizeMatchers(s, h, action, config.matchersParsed) {
 		return false
 	}
 
+	if !config.skipPreview {
+		return b.silencePreview(s, h, config)
+	}
+
+	return b.submitSilence(s, h, config)
+}
+
+// submitSilence creates/updates the silence in Alertmanager, using an already
+// validated configuration.
+func (b *Bot) submitSilence(s disgord.Session, h *disgord.InteractionCreate, config *addConfig) (ok bool) { //nolint:unparam
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
 	createParams := &silence.PostSilencesParams{}
-	createParams.SetContext(b.ctx)
+	createParams.SetContext(ctx)
 	createParams.SetTimeout(httpRequestTimeout)
 	createParams.SetSilence(&almodels.PostableSilence{
 		ID: config.id,
@@ -119,7 +185,7 @@ func (b *Bot) addOrUpdateSilence(s disgord.Session, h *disgord.InteractionCreate
 		},
 	})
 
-	createResp, err := b.al.Silence.PostSilences(createParams, b.al.HandleAuth)
+	createResp, err := config.al.Silence.PostSilences(createParams, config.al.HandleAuth)
 	if err != nil {
 		b.responseError(s, h, "An error occurred while creating/updating silence", err)
 		return false
@@ -128,22 +194,34 @@ func (b *Bot) addOrUpdateSilence(s disgord.Session, h *disgord.InteractionCreate
 	// Assuming there were no issues, refetch to get status info.
 
 	getParams := &silence.GetSilenceParams{}
-	getParams.SetContext(b.ctx)
+	getParams.SetContext(ctx)
 	getParams.SetTimeout(httpRequestTimeout)
 	getParams.SetSilenceID(strfmt.UUID(createResp.Payload.SilenceID))
-	resp, err := b.al.Silence.GetSilence(getParams, b.al.HandleAuth)
+	resp, err := config.al.Silence.GetSilence(getParams, config.al.HandleAuth)
 	if err != nil {
 		b.responseError(s, h, "An error occurred while fetching silence", err)
 		return false
 	}
 
-	silenceEmbed := b.silenceEmbed(s, resp.Payload)
+	silenceEmbed := b.silenceEmbed(s, config.al, resp.Payload)
 	silenceEmbed.Color = colorSuccess
 	if config.id == "" {
 		silenceEmbed.Title = fmt.Sprintf("Silence created: %s", *resp.Payload.ID)
 	} else {
 		silenceEmbed.Title = fmt.Sprintf("Silence updated: %s", *resp.Payload.ID)
-		silenceEmbed.Description = fmt.Sprintf("replaces silence: [%s](%s)\n", config.id, b.al.SilenceURL(config.id)) + silenceEmbed.Description
+		silenceEmbed.Description = fmt.Sprintf("replaces silence: [%s](%s)\n", config.id, config.al.SilenceURL(config.id)) + silenceEmbed.Description
+
+		if config.previous != nil {
+			changes := silenceDiff(config.previous, resp.Payload, 1024) //nolint:gomnd
+			if changes == "" {
+				changes = "No changes."
+			}
+
+			silenceEmbed.Fields = append([]*disgord.EmbedField{{
+				Name:  ":twisted_rightwards_arrows: Changes",
+				Value: changes,
+			}}, silenceEmbed.Fields...)
+		}
 	}
 
 	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
@@ -151,6 +229,7 @@ func (b *Bot) addOrUpdateSilence(s disgord.Session, h *disgord.InteractionCreate
 		Data: &disgord.CreateInteractionResponseData{
 			AllowedMentions: &disgord.AllowedMentions{Parse: []string{"users"}},
 			Embeds:          []*disgord.Embed{silenceEmbed},
+			Components:      b.silenceComponents(config.al, resp.Payload),
 		},
 	})
 	if err != nil {
@@ -158,6 +237,39 @@ func (b *Bot) addOrUpdateSilence(s disgord.Session, h *disgord.InteractionCreate
 		return false
 	}
 
+	entry := &auditEntry{
+		action:   auditCreated,
+		source:   config.source,
+		al:       config.al,
+		previous: config.previous,
+		current:  resp.Payload,
+	}
+
+	// Remember where the silence was created from, for reminders, etc.
+	meta := &store.Silence{
+		ID:        *resp.Payload.ID,
+		GuildID:   h.GuildID,
+		ChannelID: h.ChannelID,
+		UserID:    h.Member.User.ID,
+		Instance:  config.al.Name,
+	}
+
+	entry.message, err = b.originalResponse(h)
+	if err != nil {
+		b.logger.WithError(err).Warn("failed to fetch original interaction response")
+	} else {
+		meta.MessageID = entry.message.ID
+	}
+
+	if err = b.store.SetSilence(b.ctx, meta); err != nil {
+		b.logger.WithError(err).Error("failed to store silence metadata")
+	}
+
+	if config.id != "" {
+		entry.action = auditUpdated
+	}
+	b.audit(h, entry)
+
 	return true
 }
 
@@ -169,7 +281,7 @@ func (b *Bot) silenceAddFromCommand(s disgord.Session, h *disgord.InteractionCre
 	config.endsAt, _ = optionsHasChild[string](h.Data.Options, "until")
 
 	if config.comment == "" || config.matchers == "" {
-		b.modalAdd(s, h, "modal-add", "Create silence", config)
+		b.modalAdd(s, h, b.customIDFor(b.instance(h), "modal-add"), "Create silence", config)
 		return
 	}
 
@@ -209,7 +321,7 @@ func (b *Bot) silenceAddFromMessage(s disgord.Session, h *disgord.InteractionCre
 				return
 			}
 
-			b.modalAdd(s, h, "modal-add", "Create silence", &addConfig{
+			b.modalAdd(s, h, b.customIDFor(b.instance(h), "modal-add"), "Create silence", &addConfig{
 				matchers: strings.Join(alertmanager.MatcherToString(matchers, false), "\n"),
 				startsAt: "now",
 				endsAt:   time.Now().Local().Add(defaultSilenceDuration).Format(time.RFC3339),
@@ -6,7 +6,6 @@ package bot
 
 import (
 	"errors"
-	"fmt"
 	"strings"
 	"time"
 
@@ -23,39 +22,45 @@ func (b *Bot) silenceEditFromMessage(s disgord.Session, h *disgord.InteractionCr
 		return
 	}
 
-	getParams := &silence.GetSilenceParams{}
-	getParams.SetContext(b.ctx)
-	getParams.SetTimeout(httpRequestTimeout)
-	getParams.SetSilenceID(strfmt.UUID(id))
-	resp, err := b.al.Silence.GetSilence(getParams, b.al.HandleAuth)
-	if err != nil {
-		b.responseError(s, h, "An error occurred while fetching silence", err)
+	b.silenceEditModal(s, h, b.instance(h), id)
+}
+
+// silenceEditModal fetches the silence, and opens the edit modal pre-filled with
+// its current values.
+func (b *Bot) silenceEditModal(s disgord.Session, h *disgord.InteractionCreate, al *alertmanager.Client, id string) {
+	alertSilence, ok := b.getSilence(s, h, al, id)
+	if !ok {
 		return
 	}
 
-	b.modalAdd(s, h, fmt.Sprintf("modal-edit/%s", id), "Update silence", &addConfig{
+	b.modalAdd(s, h, b.customIDFor(al, "modal-edit", id), "Update silence", &addConfig{
 		id:       id,
-		comment:  *resp.Payload.Comment,
-		matchers: strings.Join(alertmanager.MatcherToString(resp.Payload.Matchers, false), "\n"),
-		startsAt: time.Until(time.Time(*resp.Payload.StartsAt)).Round(time.Minute).String(),
-		endsAt:   time.Until(time.Time(*resp.Payload.EndsAt)).Round(time.Minute).String(),
+		comment:  *alertSilence.Comment,
+		matchers: strings.Join(alertmanager.MatcherToString(alertSilence.Matchers, false), "\n"),
+		startsAt: time.Until(time.Time(*alertSilence.StartsAt)).Round(time.Minute).String(),
+		endsAt:   time.Until(time.Time(*alertSilence.EndsAt)).Round(time.Minute).String(),
 	})
 }
 
 func (b *Bot) silenceEditFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
 	id, _ := optionsHasChild[string](h.Data.Options, "id")
+	al := b.instance(h)
 
 	getParams := &silence.GetSilenceParams{}
-	getParams.SetContext(b.ctx)
+	getParams.SetContext(ctx)
 	getParams.SetTimeout(httpRequestTimeout)
 	getParams.SetSilenceID(strfmt.UUID(id))
-	resp, err := b.al.Silence.GetSilence(getParams, b.al.HandleAuth)
+	resp, err := al.Silence.GetSilence(getParams, al.HandleAuth)
 	if err != nil {
 		b.responseError(s, h, "An error occurred while fetching silence", err)
 		return
 	}
 
 	config := &addConfig{
+		al:       al,
 		id:       id,
 		comment:  *resp.Payload.Comment,
 		matchers: strings.Join(alertmanager.MatcherToString(resp.Payload.Matchers, false), "\n"),
@@ -88,7 +93,7 @@ func (b *Bot) silenceEditFromCommand(s disgord.Session, h *disgord.InteractionCr
 	// If they only provided the ID via the command, then we want to show the
 	// modal, otherwise we can just update the silence with the fields they provided.
 	if wantsModal {
-		b.modalAdd(s, h, fmt.Sprintf("modal-edit/%s", id), "Update silence", config)
+		b.modalAdd(s, h, b.customIDFor(al, "modal-edit", id), "Update silence", config)
 		return
 	}
 
@@ -19,13 +19,17 @@ import (
 )
 
 func (b *Bot) silenceGetFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
 	id, _ := optionsHasChild[string](h.Data.Options, "id")
+	al := b.instance(h)
 
 	getParams := &silence.GetSilenceParams{}
-	getParams.SetContext(b.ctx)
+	getParams.SetContext(ctx)
 	getParams.SetTimeout(httpRequestTimeout)
 	getParams.SetSilenceID(strfmt.UUID(id))
-	resp, err := b.al.Silence.GetSilence(getParams, b.al.HandleAuth)
+	resp, err := al.Silence.GetSilence(getParams, al.HandleAuth)
 	if err != nil {
 		b.responseError(s, h, "An error occurred while fetching silence", err)
 		return
@@ -34,8 +38,9 @@ func (b *Bot) silenceGetFromCommand(s disgord.Session, h *disgord.InteractionCre
 	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
 		Type: disgord.InteractionCallbackChannelMessageWithSource,
 		Data: &disgord.CreateInteractionResponseData{
-			Flags:  disgord.MessageFlagEphemeral,
-			Embeds: []*disgord.Embed{b.silenceEmbed(s, resp.Payload)},
+			Flags:      disgord.MessageFlagEphemeral,
+			Embeds:     []*disgord.Embed{b.silenceEmbed(s, al, resp.Payload)},
+			Components: b.silenceComponents(al, resp.Payload),
 		},
 	})
 	if err != nil {
@@ -45,7 +50,7 @@ func (b *Bot) silenceGetFromCommand(s disgord.Session, h *disgord.InteractionCre
 
 var reDiscordUsername = regexp.MustCompile(`<@!?(\d+)>\s+?\(([^)]+)\)`)
 
-func (b *Bot) silenceEmbed(s disgord.Session, alertSilence *almodels.GettableSilence) *disgord.Embed {
+func (b *Bot) silenceEmbed(s disgord.Session, al *alertmanager.Client, alertSilence *almodels.GettableSilence) *disgord.Embed {
 	fields := []*disgord.EmbedField{}
 
 	// All key-value label pairs.
@@ -110,12 +115,16 @@ func (b *Bot) silenceEmbed(s disgord.Session, alertSilence *almodels.GettableSil
 		Inline: true,
 	})
 
+	if field := b.instanceField(al); field != nil {
+		fields = append(fields, field)
+	}
+
 	return &disgord.Embed{
 		Type:        disgord.EmbedTypeRich,
 		Color:       color,
 		Title:       fmt.Sprintf("%s: %s", titlePrefix, *alertSilence.ID),
 		Description: "```\n" + description + "```",
-		URL:         b.al.SilenceURL(*alertSilence.ID),
+		URL:         al.SilenceURL(*alertSilence.ID),
 		Fields:      fields,
 		Timestamp:   disgord.Time{Time: time.Time(*alertSilence.UpdatedAt)},
 		Footer: &disgord.EmbedFooter{
@@ -5,58 +5,380 @@
 package bot
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/andersfylling/disgord"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
 	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/slices"
 )
 
-func (b *Bot) silenceListFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
-	filter, _ := optionsHasChild[string](h.Data.Options, "filter")
-	includeExpired, _ := optionsHasChild[bool](h.Data.Options, "include-expired")
-	expiredOnly, _ := optionsHasChild[bool](h.Data.Options, "expired-only")
+const (
+	silenceQueryTTL = time.Hour
+
+	silencesPerPage        = 5
+	silencesPerCompactPage = 15
+	maxCompactMatchers     = 100
+	maxMessageEmbedsLength = 6000
+
+	sortEndsSoonest = "ends"
+	sortNewest      = "newest"
+	sortCreator     = "creator"
+)
+
+// silenceQuery holds the options for a silence list, so the list can be re-rendered
+// when navigating between pages.
+type silenceQuery struct {
+	al             *alertmanager.Client
+	filter         []string
+	includeExpired bool
+	expiredOnly    bool
+	compact        bool
+	sort           string
+}
 
+// fetchSilences returns all silences matching the query, sorted.
+func (b *Bot) fetchSilences(ctx context.Context, query *silenceQuery) ([]*almodels.GettableSilence, error) {
 	params := &silence.GetSilencesParams{}
-	params.SetContext(b.ctx)
+	params.SetContext(ctx)
 	params.SetTimeout(httpRequestTimeout)
 
-	if filter != "" {
-		params.SetFilter([]string{filter})
+	if len(query.filter) > 0 {
+		params.SetFilter(query.filter)
 	}
 
-	silences, err := b.al.Silence.GetSilences(params, b.al.HandleAuth)
+	resp, err := query.al.Silence.GetSilences(params, query.al.HandleAuth)
 	if err != nil {
-		b.responseError(s, h, "An error occurred while fetching silences", err)
-		return
+		return nil, err
 	}
 
-	var embeds []*disgord.Embed
+	var silences []*almodels.GettableSilence
 
-	for _, alertSilence := range silences.Payload {
-		if expiredOnly && *alertSilence.Status.State != "expired" {
+	for _, alertSilence := range resp.Payload {
+		if query.expiredOnly && *alertSilence.Status.State != "expired" {
 			continue
-		} else if !expiredOnly && !includeExpired && *alertSilence.Status.State != "active" {
+		} else if !query.expiredOnly && !query.includeExpired && *alertSilence.Status.State != "active" {
 			continue
 		}
 
-		embeds = append(embeds, b.silenceEmbed(s, alertSilence))
+		silences = append(silences, alertSilence)
+	}
+
+	slices.SortStableFunc(silences, func(x, y *almodels.GettableSilence) bool {
+		switch query.sort {
+		case sortNewest:
+			return time.Time(*x.StartsAt).After(time.Time(*y.StartsAt))
+		case sortCreator:
+			if *x.CreatedBy != *y.CreatedBy {
+				return strings.ToLower(*x.CreatedBy) < strings.ToLower(*y.CreatedBy)
+			}
+		}
+
+		return time.Time(*x.EndsAt).Before(time.Time(*y.EndsAt))
+	})
+
+	return silences, nil
+}
+
+// embedsLength returns the total number of characters in the embeds, which
+// Discord limits per message.
+func embedsLength(embeds []*disgord.Embed) (length int) {
+	for _, embed := range embeds {
+		length += len([]rune(embed.Title)) + len([]rune(embed.Description))
+
+		for _, field := range embed.Fields {
+			length += len([]rune(field.Name)) + len([]rune(field.Value))
+		}
+
+		if embed.Footer != nil {
+			length += len([]rune(embed.Footer.Text))
+		}
+	}
+
+	return length
+}
+
+// fitEmbeds drops embeds from the end until they fit within Discord's limits on
+// the number of embeds in a message, and their total length. If any embeds were
+// dropped (or omitted is already non-zero), an embed generated by summary, with
+// the number of omitted entries, is added at the end.
+func fitEmbeds(embeds []*disgord.Embed, omitted int, summary func(omitted int) *disgord.Embed) []*disgord.Embed {
+	for {
+		result := embeds
+		if omitted > 0 {
+			result = append(embeds[:len(embeds):len(embeds)], summary(omitted))
+		}
+
+		if len(embeds) == 0 || (len(result) <= maxMessageEmbeds && embedsLength(result) <= maxMessageEmbedsLength) {
+			return result
+		}
+
+		embeds = embeds[:len(embeds)-1]
+		omitted++
+	}
+}
+
+// silenceCompactLine returns a single line summary of a silence.
+func silenceCompactLine(al *alertmanager.Client, alertSilence *almodels.GettableSilence) string {
+	creator := *alertSilence.CreatedBy
+	if match := reDiscordUsername.FindStringSubmatch(creator); len(match) == 3 { //nolint:gomnd
+		creator = fmt.Sprintf("<@%s>", match[1])
+	}
+
+	verb := "ends"
+	if *alertSilence.Status.State == "expired" {
+		verb = "ended"
+	}
+
+	return fmt.Sprintf(
+		"[`%s`](%s) `%s` %s <t:%d:R> by %s",
+		(*alertSilence.ID)[:8],
+		al.SilenceURL(*alertSilence.ID),
+		truncate(strings.Join(alertmanager.MatcherToString(alertSilence.Matchers, false), ", "), maxCompactMatchers),
+		verb,
+		time.Time(*alertSilence.EndsAt).Unix(),
+		creator,
+	)
+}
+
+// silenceListPage renders a single page of the silence list. The page is clamped
+// to the available pages, as silences may have been added/removed since the list
+// was first rendered.
+func (b *Bot) silenceListPage(
+	ctx context.Context,
+	s disgord.Session,
+	key string,
+	query *silenceQuery,
+	page int,
+) (*disgord.CreateInteractionResponseData, error) {
+	silences, err := b.fetchSilences(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	perPage := silencesPerPage
+	if query.compact {
+		perPage = silencesPerCompactPage
+	}
+
+	pages := (len(silences) + perPage - 1) / perPage
+	if pages < 1 {
+		pages = 1
+	}
+
+	if page < 1 {
+		page = 1
+	} else if page > pages {
+		page = pages
+	}
+
+	start := (page - 1) * perPage
+	end := start + perPage
+	if end > len(silences) {
+		end = len(silences)
+	}
+
+	var embeds []*disgord.Embed
+
+	if !query.compact {
+		for _, alertSilence := range silences[start:end] {
+			embeds = append(embeds, b.silenceEmbed(s, query.al, alertSilence))
+		}
+	}
+
+	// Fallback to the compact format if the silences are too large to fit in a
+	// single message.
+	if query.compact || embedsLength(embeds) > maxMessageEmbedsLength {
+		lines := make([]string, 0, end-start)
+		for _, alertSilence := range silences[start:end] {
+			lines = append(lines, silenceCompactLine(query.al, alertSilence))
+		}
+
+		embeds = []*disgord.Embed{{
+			Type:        disgord.EmbedTypeRich,
+			Color:       colorInfo,
+			Title:       "Silences",
+			Description: truncate(strings.Join(lines, "\n"), maxEmbedDesc),
+		}}
 	}
 
-	if len(embeds) == 0 {
-		embeds = append(embeds, &disgord.Embed{
+	if len(silences) == 0 {
+		embeds = []*disgord.Embed{{
 			Type:  disgord.EmbedTypeRich,
 			Color: colorInfo,
 			Title: "No active silences",
-		})
+		}}
+	}
+
+	content := fmt.Sprintf("Page %d/%d (%d silences)", page, pages, len(silences))
+	if b.instances.Multiple() {
+		content = fmt.Sprintf("Page %d/%d (%d silences in `%s`)", page, pages, len(silences), query.al.Name)
+	}
+
+	data := &disgord.CreateInteractionResponseData{
+		Flags:           disgord.MessageFlagEphemeral,
+		Content:         content,
+		Embeds:          embeds,
+		AllowedMentions: &disgord.AllowedMentions{Parse: []string{}},
+		Components:      []*disgord.MessageComponent{},
+	}
+
+	if pages > 1 {
+		data.Components = []*disgord.MessageComponent{{
+			Type: disgord.MessageComponentActionRow,
+			Components: []*disgord.MessageComponent{
+				{
+					Type:     disgord.MessageComponentButton,
+					Style:    disgord.Secondary,
+					Label:    "Previous",
+					CustomID: fmt.Sprintf("silences-page/%s/%d", key, page-1),
+					Disabled: page <= 1,
+				},
+				{
+					Type:     disgord.MessageComponentButton,
+					Style:    disgord.Secondary,
+					Label:    "Jump to page",
+					CustomID: fmt.Sprintf("silences-jump/%s", key),
+				},
+				{
+					Type:     disgord.MessageComponentButton,
+					Style:    disgord.Secondary,
+					Label:    "Next",
+					CustomID: fmt.Sprintf("silences-page/%s/%d", key, page+1),
+					Disabled: page >= pages,
+				},
+			},
+		}}
+	}
+
+	return data, nil
+}
+
+func (b *Bot) silenceListFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
+	query := &silenceQuery{al: b.instance(h), sort: sortEndsSoonest}
+
+	if filter, _ := optionsHasChild[string](h.Data.Options, "filter"); filter != "" {
+		matchers, err := alertmanager.ParseLabels(filter, true)
+		if err != nil {
+			b.responseError(s, h, "Invalid filter provided", err)
+			return
+		}
+
+		query.filter = alertmanager.MatcherToString(matchers, false)
+	}
+
+	query.includeExpired, _ = optionsHasChild[bool](h.Data.Options, "include-expired")
+	query.expiredOnly, _ = optionsHasChild[bool](h.Data.Options, "expired-only")
+	query.compact, _ = optionsHasChild[bool](h.Data.Options, "compact")
+
+	if v, ok := optionsHasChild[string](h.Data.Options, "sort"); ok {
+		query.sort = v
+	}
+
+	key := b.silenceQueries.Set(query)
+
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	data, err := b.silenceListPage(ctx, s, key, query, 1)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching silences", err)
+		return
 	}
 
 	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
 		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+// silenceListUpdate re-renders the silence list message at the provided page.
+func (b *Bot) silenceListUpdate(s disgord.Session, h *disgord.InteractionCreate, key string, page int) {
+	query, ok := b.silenceQueries.Get(key)
+	if !ok {
+		b.responseError(s, h, "Silence list expired", errors.New("Please run the `/silences list` command again.")) //nolint:revive,stylecheck
+		return
+	}
+
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	data, err := b.silenceListPage(ctx, s, key, query, page)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching silences", err)
+		return
+	}
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackUpdateMessage,
+		Data: data,
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
+
+func (b *Bot) silenceListPageFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	if len(args) < 2 { //nolint:gomnd
+		return
+	}
+
+	page, err := strconv.Atoi(args[1])
+	if err != nil {
+		b.responseError(s, h, "Invalid page", err)
+		return
+	}
+
+	b.silenceListUpdate(s, h, args[0], page)
+}
+
+func (b *Bot) silenceListJumpFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	if len(args) < 1 {
+		return
+	}
+
+	err := s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackModal,
 		Data: &disgord.CreateInteractionResponseData{
-			Flags:           disgord.MessageFlagEphemeral,
-			Embeds:          embeds,
-			AllowedMentions: &disgord.AllowedMentions{Parse: []string{"users"}},
+			Title:    "Jump to page",
+			CustomID: fmt.Sprintf("modal-silences-jump/%s", args[0]),
+			Components: []*disgord.MessageComponent{{
+				Type: disgord.MessageComponentActionRow,
+				Components: []*disgord.MessageComponent{{
+					Type:        disgord.MessageComponentTextInput,
+					Style:       disgord.TextInputStyleShort,
+					Required:    true,
+					CustomID:    "page",
+					Label:       "Page number",
+					Placeholder: "1",
+				}},
+			}},
 		},
 	})
 	if err != nil {
 		b.logger.WithError(err).Error("failed to respond to interaction")
 	}
 }
+
+func (b *Bot) silenceListJumpFromModalCallback(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	if len(args) < 1 {
+		return
+	}
+
+	page, ok := componentsHasChild[int64](h.Data.Components, "page")
+	if !ok {
+		b.responseError(s, h, "Invalid page", errors.New("page must be a number"))
+		return
+	}
+
+	b.silenceListUpdate(s, h, args[0], int(page))
+}
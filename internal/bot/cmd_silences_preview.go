@@ -0,0 +1,279 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/prometheus/alertmanager/api/v2/client/alert"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	pendingSilenceTTL = 15 * time.Minute
+	previewSampleSize = 10
+
+	// alertsTTL is how long alerts are cached for autocomplete. Kept short, as
+	// alerts change far more often than receivers.
+	alertsTTL = 5 * time.Second
+)
+
+// pendingSilence is a validated silence, waiting for the user to confirm it.
+type pendingSilence struct {
+	userID disgord.Snowflake
+	config *addConfig
+}
+
+// alertSummary returns a single-line summary of an alert, using its name and
+// the rest of its labels.
+func alertSummary(labels map[string]string) string {
+	names := maps.Keys(labels)
+	slices.Sort(names)
+
+	var pairs []string
+	for _, name := range names {
+		if name == "alertname" {
+			continue
+		}
+
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+
+	return labels["alertname"] + "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// getAlerts fetches all alerts known to the instance.
+func (b *Bot) getAlerts(ctx context.Context, al *alertmanager.Client) ([]*almodels.GettableAlert, error) {
+	params := &alert.GetAlertsParams{}
+	params.SetContext(ctx)
+	params.SetTimeout(httpRequestTimeout)
+
+	alerts, err := al.Alert.GetAlerts(params, al.HandleAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts.Payload, nil
+}
+
+// cachedAlerts is like getAlerts, but caches the alerts for a short period, as
+// autocomplete otherwise fetches them on every keystroke.
+func (b *Bot) cachedAlerts(ctx context.Context, al *alertmanager.Client) ([]*almodels.GettableAlert, error) {
+	if alerts, ok := b.alerts.Get(al.Name); ok {
+		return alerts, nil
+	}
+
+	alerts, err := b.getAlerts(ctx, al)
+	if err != nil {
+		return nil, err
+	}
+
+	b.alerts.SetKey(al.Name, alerts)
+	return alerts, nil
+}
+
+// filterAlerts returns the alerts which would be matched by the provided matchers.
+func filterAlerts(alerts []*almodels.GettableAlert, matchers []*almodels.Matcher) ([]*almodels.GettableAlert, error) {
+	compiled, err := alertmanager.CompileMatchers(matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*almodels.GettableAlert
+	for _, alertEntry := range alerts {
+		if compiled.Matches(alertEntry.Labels) {
+			matched = append(matched, alertEntry)
+		}
+	}
+
+	return matched, nil
+}
+
+// matchingAlerts fetches all alerts known to the instance, and returns the ones
+// which would be matched by the provided matchers.
+func (b *Bot) matchingAlerts(ctx context.Context, al *alertmanager.Client, matchers []*almodels.Matcher) ([]*almodels.GettableAlert, error) {
+	alerts, err := b.getAlerts(ctx, al)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterAlerts(alerts, matchers)
+}
+
+// silencePreview shows which alerts the silence would match, and asks the user
+// to confirm before the silence is actually created/updated.
+func (b *Bot) silencePreview(s disgord.Session, h *disgord.InteractionCreate, config *addConfig) (ok bool) {
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
+	matched, err := b.matchingAlerts(ctx, config.al, config.matchersParsed)
+	if err != nil {
+		b.responseError(s, h, "An error occurred while fetching alerts to preview silence", err)
+		return false
+	}
+
+	if err = config.checkMatchedGuardrails(b.config.Guardrails, len(matched)); err != nil {
+		b.responseValidationError(s, h, err)
+		return false
+	}
+
+	key := b.pendingSilences.Set(&pendingSilence{
+		userID: h.Member.User.ID,
+		config: config,
+	})
+
+	color := colorInfo
+	summary := fmt.Sprintf("This silence currently matches **%d** alert(s).", len(matched))
+	if len(matched) == 0 {
+		color = colorWarning
+		summary = "This silence currently matches **no** alerts. Double check the matchers for typos."
+	}
+
+	fields := []*disgord.EmbedField{
+		{
+			Name:   ":memo: Comment",
+			Value:  config.comment,
+			Inline: false,
+		},
+		{
+			Name:   ":watch: Starts",
+			Value:  fmt.Sprintf("<t:%d:R>", config.startsAtParsed.Unix()),
+			Inline: true,
+		},
+		{
+			Name:   ":watch: Ends",
+			Value:  fmt.Sprintf("<t:%d:R>", config.endsAtParsed.Unix()),
+			Inline: true,
+		},
+	}
+
+	if field := b.instanceField(config.al); field != nil {
+		fields = append(fields, field)
+	}
+
+	if len(matched) > 0 {
+		var sample []string
+		for i, alertEntry := range matched {
+			if i >= previewSampleSize {
+				sample = append(sample, fmt.Sprintf("... and %d more", len(matched)-i))
+				break
+			}
+
+			sample = append(sample, truncate(alertSummary(alertEntry.Labels), 100)) //nolint:gomnd
+		}
+
+		fields = append(fields, &disgord.EmbedField{
+			Name:   ":bell: Affected alerts",
+			Value:  truncate("```\n"+strings.Join(sample, "\n"), 1020) + "\n```", //nolint:gomnd
+			Inline: false,
+		})
+	}
+
+	title := "Confirm new silence"
+	if config.id != "" {
+		title = fmt.Sprintf("Confirm update to silence: %s", config.id)
+	}
+
+	err = s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackChannelMessageWithSource,
+		Data: &disgord.CreateInteractionResponseData{
+			Flags: disgord.MessageFlagEphemeral,
+			Embeds: []*disgord.Embed{{
+				Type:  disgord.EmbedTypeRich,
+				Color: color,
+				Title: title,
+				Description: summary + "\n```\n" + strings.Join(
+					alertmanager.MatcherToString(config.matchersParsed, true), "\n",
+				) + "\n```",
+				Fields: fields,
+			}},
+			Components: []*disgord.MessageComponent{{
+				Type: disgord.MessageComponentActionRow,
+				Components: []*disgord.MessageComponent{
+					{
+						Type:     disgord.MessageComponentButton,
+						Style:    disgord.Success,
+						Label:    "Confirm",
+						CustomID: fmt.Sprintf("silence-confirm/%s", key),
+					},
+					{
+						Type:     disgord.MessageComponentButton,
+						Style:    disgord.Secondary,
+						Label:    "Cancel",
+						CustomID: fmt.Sprintf("silence-cancel/%s", key),
+					},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+		return false
+	}
+
+	return true
+}
+
+// pendingFromButton fetches the pending silence associated with the button, making
+// sure the user clicking it is the same user that requested the silence.
+func (b *Bot) pendingFromButton(s disgord.Session, h *disgord.InteractionCreate, args []string) (*pendingSilence, bool) {
+	if len(args) < 1 {
+		return nil, false
+	}
+
+	pending, ok := b.pendingSilences.Get(args[0])
+	if !ok {
+		b.responseError(s, h, "Silence confirmation expired", errors.New("This confirmation has expired, please try again.")) //nolint:revive,stylecheck
+		return nil, false
+	}
+
+	if pending.userID != h.Member.User.ID {
+		b.responseError(s, h, "Unable to confirm silence", errors.New("Only the user who requested the silence can confirm it.")) //nolint:revive,stylecheck
+		return nil, false
+	}
+
+	return pending, true
+}
+
+func (b *Bot) silenceConfirmFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	pending, ok := b.pendingFromButton(s, h, args)
+	if !ok {
+		return
+	}
+
+	b.pendingSilences.Delete(args[0])
+	_ = b.submitSilence(s, h, pending.config)
+}
+
+func (b *Bot) silenceCancelFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	if _, ok := b.pendingFromButton(s, h, args); !ok {
+		return
+	}
+
+	b.pendingSilences.Delete(args[0])
+
+	err := s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackUpdateMessage,
+		Data: &disgord.CreateInteractionResponseData{
+			Embeds: []*disgord.Embed{{
+				Type:  disgord.EmbedTypeRich,
+				Color: colorExpired,
+				Title: "Silence cancelled",
+			}},
+			Components: []*disgord.MessageComponent{},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
@@ -10,17 +10,21 @@ import (
 
 	"github.com/andersfylling/disgord"
 	"github.com/go-openapi/strfmt"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
 	"github.com/prometheus/alertmanager/api/v2/client/silence"
 )
 
-func (b *Bot) silenceRemove(s disgord.Session, h *disgord.InteractionCreate, id string) (ok bool) { //nolint:unparam
+func (b *Bot) silenceRemove(s disgord.Session, h *disgord.InteractionCreate, al *alertmanager.Client, id string) (ok bool) { //nolint:unparam
+	ctx, cancel := b.interactionContext(h)
+	defer cancel()
+
 	// First get the silence, so we can show it in the response to make it clear
 	// to others in the same channel what was removed.
 	getParams := &silence.GetSilenceParams{}
-	getParams.SetContext(b.ctx)
+	getParams.SetContext(ctx)
 	getParams.SetTimeout(httpRequestTimeout)
 	getParams.SetSilenceID(strfmt.UUID(id))
-	resp, err := b.al.Silence.GetSilence(getParams, b.al.HandleAuth)
+	resp, err := al.Silence.GetSilence(getParams, al.HandleAuth)
 	if err != nil {
 		b.responseError(s, h, "An error occurred while fetching silence", err)
 		return false
@@ -31,18 +35,22 @@ func (b *Bot) silenceRemove(s disgord.Session, h *disgord.InteractionCreate, id
 		return false
 	}
 
+	if !b.authorizeMatchers(s, h, actionRemove, resp.Payload.Matchers) {
+		return false
+	}
+
 	deleteParams := &silence.DeleteSilenceParams{}
-	deleteParams.SetContext(b.ctx)
+	deleteParams.SetContext(ctx)
 	deleteParams.SetTimeout(httpRequestTimeout)
 	deleteParams.SetSilenceID(strfmt.UUID(id))
 
-	_, err = b.al.Silence.DeleteSilence(deleteParams, b.al.HandleAuth)
+	_, err = al.Silence.DeleteSilence(deleteParams, al.HandleAuth)
 	if err != nil {
 		b.responseError(s, h, "An error occurred while deleting silence", err)
 		return false
 	}
 
-	silenceEmbed := b.silenceEmbed(s, resp.Payload)
+	silenceEmbed := b.silenceEmbed(s, al, resp.Payload)
 	silenceEmbed.Color = colorError
 	silenceEmbed.Title = "Silence removed"
 	silenceEmbed.URL = ""
@@ -59,6 +67,8 @@ func (b *Bot) silenceRemove(s disgord.Session, h *disgord.InteractionCreate, id
 		return false
 	}
 
+	b.audit(h, &auditEntry{action: auditRemoved, source: interactionSource(h), al: al, previous: resp.Payload})
+
 	return true
 }
 
@@ -69,11 +79,11 @@ func (b *Bot) silenceRemoveFromMessage(s disgord.Session, h *disgord.Interaction
 		return
 	}
 
-	_ = b.silenceRemove(s, h, id)
+	_ = b.silenceRemove(s, h, b.instance(h), id)
 }
 
 func (b *Bot) silenceRemoveFromCommand(s disgord.Session, h *disgord.InteractionCreate) {
 	id, _ := optionsHasChild[string](h.Data.Options, "id")
 
-	_ = b.silenceRemove(s, h, id)
+	_ = b.silenceRemove(s, h, b.instance(h), id)
 }
@@ -58,11 +58,12 @@ var commands = []*disgord.CreateApplicationCommand{
 						MinLength:   4,
 					},
 					{
-						Name:        "filter",
-						Description: "Filter silences by label-value pairs. e.g. alertname=\"foo\",bar=\"baz\"",
-						Type:        disgord.OptionTypeString,
-						Required:    false,
-						MinLength:   4,
+						Name:         "filter",
+						Description:  "Filter silences by label-value pairs. e.g. alertname=\"foo\",bar=\"baz\"",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						MinLength:    4,
+						Autocomplete: true,
 					},
 					{
 						Name:        "at",
@@ -84,12 +85,13 @@ var commands = []*disgord.CreateApplicationCommand{
 				Type:        disgord.OptionTypeSubCommand,
 				Options: []*disgord.ApplicationCommandOption{
 					{
-						Name:        "id",
-						Description: "Silence ID to get",
-						Type:        disgord.OptionTypeString,
-						Required:    true,
-						MinLength:   36,
-						MaxLength:   36,
+						Name:         "id",
+						Description:  "Silence ID to get",
+						Type:         disgord.OptionTypeString,
+						Required:     true,
+						MinLength:    36,
+						MaxLength:    36,
+						Autocomplete: true,
 					},
 				},
 			},
@@ -99,12 +101,13 @@ var commands = []*disgord.CreateApplicationCommand{
 				Type:        disgord.OptionTypeSubCommand,
 				Options: []*disgord.ApplicationCommandOption{
 					{
-						Name:        "id",
-						Description: "Silence ID to edit",
-						Type:        disgord.OptionTypeString,
-						Required:    true,
-						MinLength:   36,
-						MaxLength:   36,
+						Name:         "id",
+						Description:  "Silence ID to edit",
+						Type:         disgord.OptionTypeString,
+						Required:     true,
+						MinLength:    36,
+						MaxLength:    36,
+						Autocomplete: true,
 					},
 					{
 						Name:        "comment",
@@ -114,11 +117,12 @@ var commands = []*disgord.CreateApplicationCommand{
 						MinLength:   4,
 					},
 					{
-						Name:        "filter",
-						Description: "Filter silences by label-value pairs. e.g. alertname=\"foo\",bar=\"baz\"",
-						Type:        disgord.OptionTypeString,
-						Required:    false,
-						MinLength:   4,
+						Name:         "filter",
+						Description:  "Filter silences by label-value pairs. e.g. alertname=\"foo\",bar=\"baz\"",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						MinLength:    4,
+						Autocomplete: true,
 					},
 					{
 						Name:        "at",
@@ -140,11 +144,12 @@ var commands = []*disgord.CreateApplicationCommand{
 				Type:        disgord.OptionTypeSubCommand,
 				Options: []*disgord.ApplicationCommandOption{
 					{
-						Name:        "filter",
-						Description: "Filter silences by label-value pairs. e.g. alertname=\"foo\",bar=\"baz\"",
-						Type:        disgord.OptionTypeString,
-						Required:    false,
-						MinLength:   4,
+						Name:         "filter",
+						Description:  "Filter silences by label-value pairs. e.g. alertname=\"foo\",bar=\"baz\"",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						MinLength:    4,
+						Autocomplete: true,
 					},
 					{
 						Name:        "include-expired",
@@ -158,6 +163,23 @@ var commands = []*disgord.CreateApplicationCommand{
 						Type:        disgord.OptionTypeBoolean,
 						Required:    false,
 					},
+					{
+						Name:        "sort",
+						Description: "Order to show silences in (defaults to ends soonest)",
+						Type:        disgord.OptionTypeString,
+						Required:    false,
+						Choices: []*disgord.ApplicationCommandOptionChoice{
+							{Name: "Ends soonest", Value: "ends"},
+							{Name: "Newest", Value: "newest"},
+							{Name: "Creator", Value: "creator"},
+						},
+					},
+					{
+						Name:        "compact",
+						Description: "Show one line per silence, rather than full details",
+						Type:        disgord.OptionTypeBoolean,
+						Required:    false,
+					},
 				},
 			},
 			{
@@ -166,12 +188,285 @@ var commands = []*disgord.CreateApplicationCommand{
 				Type:        disgord.OptionTypeSubCommand,
 				Options: []*disgord.ApplicationCommandOption{
 					{
-						Name:        "id",
-						Description: "Silence ID to remove",
+						Name:         "id",
+						Description:  "Silence ID to remove",
+						Type:         disgord.OptionTypeString,
+						Required:     true,
+						MinLength:    36,
+						MaxLength:    36,
+						Autocomplete: true,
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:                     "alerts",
+		Description:              "View, fire and resolve alerts",
+		DMPermission:             models.Ptr(false),
+		DefaultMemberPermissions: models.Ptr(disgord.PermissionBit(0)),
+		Options: []*disgord.ApplicationCommandOption{
+			{
+				Name:        "list",
+				Description: "Lists alerts currently known to Alertmanager",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:         "filter",
+						Description:  "Filter alerts by label-value pairs. e.g. alertname=\"foo\",bar=\"baz\"",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						MinLength:    4,
+						Autocomplete: true,
+					},
+					{
+						Name:        "active",
+						Description: "Include active alerts (default: true)",
+						Type:        disgord.OptionTypeBoolean,
+						Required:    false,
+					},
+					{
+						Name:        "silenced",
+						Description: "Include silenced alerts (default: true)",
+						Type:        disgord.OptionTypeBoolean,
+						Required:    false,
+					},
+					{
+						Name:        "inhibited",
+						Description: "Include inhibited alerts (default: true)",
+						Type:        disgord.OptionTypeBoolean,
+						Required:    false,
+					},
+					{
+						Name:        "unprocessed",
+						Description: "Include unprocessed alerts (default: true)",
+						Type:        disgord.OptionTypeBoolean,
+						Required:    false,
+					},
+					{
+						Name:         "receiver",
+						Description:  "Only include alerts for receivers matching the provided regex",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Name:        "groups",
+				Description: "Lists alert groups, as grouped by Alertmanager for each receiver",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:         "filter",
+						Description:  "Filter alerts by label-value pairs. e.g. alertname=\"foo\",bar=\"baz\"",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						MinLength:    4,
+						Autocomplete: true,
+					},
+					{
+						Name:         "receiver",
+						Description:  "Only include groups for receivers matching the provided regex",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Name:        "fire",
+				Description: "Fire an alert, which is routed the same as any other alert (opens a form if no labels are provided)",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:         "labels",
+						Description:  `Alert labels, e.g. alertname="CustomerReportedOutage", severity="critical"`,
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						Autocomplete: true,
+					},
+					{
+						Name:        "annotations",
+						Description: `Alert annotations, e.g. summary="Customers are unable to log in"`,
+						Type:        disgord.OptionTypeString,
+						Required:    false,
+					},
+					{
+						Name:        "duration",
+						Description: "How long until the alert resolves on its own (default: 1h)",
 						Type:        disgord.OptionTypeString,
-						Required:    true,
-						MinLength:   36,
-						MaxLength:   36,
+						Required:    false,
+					},
+				},
+			},
+			{
+				Name:        "resolve",
+				Description: "Resolve alerts which were fired with /alerts fire",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:         "filter",
+						Description:  "Filter alerts by label-value pairs. e.g. alertname=\"foo\",bar=\"baz\"",
+						Type:         disgord.OptionTypeString,
+						Required:     true,
+						MinLength:    4,
+						Autocomplete: true,
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:                     "alertmanager",
+		Description:              "View and configure Alertmanager instances",
+		DMPermission:             models.Ptr(false),
+		DefaultMemberPermissions: models.Ptr(disgord.PermissionBit(0)),
+		Options: []*disgord.ApplicationCommandOption{
+			{
+				Name:        "instances",
+				Description: "Lists configured Alertmanager instances",
+				Type:        disgord.OptionTypeSubCommand,
+			},
+			{
+				Name:        "status",
+				Description: "Show Alertmanager version, uptime, cluster and configuration status",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:         "instance",
+						Description:  "Alertmanager instance to show (defaults to the channel default)",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Name:        "routes",
+				Description: "Show the Alertmanager routing tree",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:         "instance",
+						Description:  "Alertmanager instance to show (defaults to the channel default)",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Name:        "route-test",
+				Description: "Test which receivers an alert with the provided labels would be routed to",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:         "labels",
+						Description:  `Alert labels, e.g. alertname="HighLatency", severity="critical"`,
+						Type:         disgord.OptionTypeString,
+						Required:     true,
+						Autocomplete: true,
+					},
+					{
+						Name:         "instance",
+						Description:  "Alertmanager instance to test against (defaults to the channel default)",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Name:        "receivers",
+				Description: "Lists Alertmanager receivers",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:         "instance",
+						Description:  "Alertmanager instance to show (defaults to the channel default)",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Name:        "receiver-test",
+				Description: "Send a test alert routed to a receiver, and check that Alertmanager routed it",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:         "receiver",
+						Description:  "Receiver to send the test alert to",
+						Type:         disgord.OptionTypeString,
+						Required:     true,
+						Autocomplete: true,
+					},
+					{
+						Name:         "labels",
+						Description:  `Additional alert labels, e.g. team="db" (routing labels are added automatically)`,
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						Autocomplete: true,
+					},
+					{
+						Name:         "instance",
+						Description:  "Alertmanager instance to send to (defaults to the channel default)",
+						Type:         disgord.OptionTypeString,
+						Required:     false,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Name:        "set-default",
+				Description: "Set the default Alertmanager instance for commands used in this channel",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:         "instance",
+						Description:  "Alertmanager instance to use by default",
+						Type:         disgord.OptionTypeString,
+						Required:     true,
+						Autocomplete: true,
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:                     "audit",
+		Description:              "View and configure the audit history of silence changes",
+		DMPermission:             models.Ptr(false),
+		DefaultMemberPermissions: models.Ptr(disgord.PermissionBit(0)),
+		Options: []*disgord.ApplicationCommandOption{
+			{
+				Name:        "history",
+				Description: "Show the most recent silence changes made through the bot",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:        "limit",
+						Description: "Number of changes to show (default: 10)",
+						Type:        disgord.OptionTypeInteger,
+						Required:    false,
+						MinValue:    1,
+						MaxValue:    maxAuditHistory,
+					},
+				},
+			},
+			{
+				Name:        "set-channel",
+				Description: "Set the channel silence changes are posted to (no channel uses the configured default)",
+				Type:        disgord.OptionTypeSubCommand,
+				Options: []*disgord.ApplicationCommandOption{
+					{
+						Name:         "channel",
+						Description:  "Channel to post silence changes to",
+						Type:         disgord.OptionTypeChannel,
+						Required:     false,
+						ChannelTypes: []disgord.ChannelType{disgord.ChannelTypeGuildText},
 					},
 				},
 			},
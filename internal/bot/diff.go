@@ -0,0 +1,158 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// Time shifts smaller than this are ignored, as edits through the modal use
+	// relative durations, which are rounded to the minute.
+	diffTimeThreshold = time.Minute
+
+	// diffCommentLength is the maximum length of each side of a comment change.
+	diffCommentLength = 200
+)
+
+// matcherStrings returns the string representation of each matcher, grouped by
+// label name.
+func matcherStrings(matchers []*almodels.Matcher) map[string][]string {
+	out := make(map[string][]string)
+
+	compiled, err := alertmanager.CompileMatchers(matchers)
+	if err != nil {
+		// Shouldn't happen for silences returned by Alertmanager, but fall back
+		// to the raw representation.
+		for _, m := range alertmanager.MatcherToString(matchers, false) {
+			out[m] = append(out[m], m)
+		}
+
+		return out
+	}
+
+	for _, m := range compiled {
+		out[m.Name] = append(out[m.Name], m.String())
+	}
+
+	return out
+}
+
+// diffMatchers returns a diff-formatted list of added and removed matchers.
+// Changed matchers (same label, different operator or value) are shown as a
+// removal, followed by an addition.
+func diffMatchers(previous, current []*almodels.Matcher) (lines []string) {
+	prev := matcherStrings(previous)
+	curr := matcherStrings(current)
+
+	names := maps.Keys(prev)
+	for name := range curr {
+		if _, ok := prev[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		for _, m := range prev[name] {
+			if !slices.Contains(curr[name], m) {
+				lines = append(lines, "- "+m)
+			}
+		}
+
+		for _, m := range curr[name] {
+			if !slices.Contains(prev[name], m) {
+				lines = append(lines, "+ "+m)
+			}
+		}
+	}
+
+	return lines
+}
+
+// diffTime returns a description of how a timestamp has shifted, or an empty
+// string if it hasn't meaningfully changed.
+func diffTime(name string, previous, current time.Time) string {
+	shift := current.Sub(previous).Round(time.Minute)
+	if shift > -diffTimeThreshold && shift < diffTimeThreshold {
+		return ""
+	}
+
+	sign := "+"
+	if shift < 0 {
+		sign = "-"
+		shift = -shift
+	}
+
+	return fmt.Sprintf(
+		"**%s:** <t:%d:f> → <t:%d:f> (%s%s)",
+		name, previous.Unix(), current.Unix(), sign, shift,
+	)
+}
+
+// fencedLines returns the lines as a code block in the provided language, which
+// is at most maxLength long. Lines which don't fit are omitted (rather than cut
+// off), so the code block is always closed.
+func fencedLines(language string, lines []string, maxLength int) string {
+	open, closing := "```"+language+"\n", "```"
+
+	// Account for the code block, and the omitted line.
+	maxLength -= len([]rune(open)) + len([]rune(closing)) + 30 //nolint:gomnd
+
+	var out strings.Builder
+	length := 0
+
+	for i, line := range lines {
+		lineLength := len([]rune(line)) + 1
+		if length+lineLength > maxLength {
+			fmt.Fprintf(&out, "... and %d more\n", len(lines)-i)
+			break
+		}
+
+		out.WriteString(line + "\n")
+		length += lineLength
+	}
+
+	return open + out.String() + closing
+}
+
+// silenceDiff returns a human readable, field-by-field diff between a silence and
+// the silence which replaced it, which is at most maxLength long, or an empty
+// string if nothing changed.
+func silenceDiff(previous, current *almodels.GettableSilence, maxLength int) string {
+	var out []string
+
+	if *previous.Comment != *current.Comment {
+		out = append(out, fmt.Sprintf(
+			"**Comment:** ~~%s~~ → %s",
+			truncate(*previous.Comment, diffCommentLength), truncate(*current.Comment, diffCommentLength),
+		))
+	}
+
+	if line := diffTime("Starts", time.Time(*previous.StartsAt), time.Time(*current.StartsAt)); line != "" {
+		out = append(out, line)
+	}
+
+	if line := diffTime("Ends", time.Time(*previous.EndsAt), time.Time(*current.EndsAt)); line != "" {
+		out = append(out, line)
+	}
+
+	// Matchers come first, and get whatever is left after the other changes.
+	if lines := diffMatchers(previous.Matchers, current.Matchers); len(lines) > 0 {
+		header := "**Matchers:**\n"
+		remaining := maxLength - len([]rune(header)) - len([]rune(strings.Join(out, "\n"))) - 1
+
+		out = append([]string{header + fencedLines("diff", lines, remaining)}, out...)
+	}
+
+	return strings.Join(out, "\n")
+}
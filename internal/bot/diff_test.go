@@ -0,0 +1,157 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/lrstanley/discord-alertmanager/internal/models"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+)
+
+var diffBase = time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func testDiffMatcher(name, value string, isEqual, isRegex bool) *almodels.Matcher {
+	return &almodels.Matcher{
+		Name:    models.Ptr(name),
+		Value:   models.Ptr(value),
+		IsEqual: models.Ptr(isEqual),
+		IsRegex: models.Ptr(isRegex),
+	}
+}
+
+func testDiffSilence(comment string, startsAt, endsAt time.Duration, matchers ...*almodels.Matcher) *almodels.GettableSilence {
+	return &almodels.GettableSilence{
+		Silence: almodels.Silence{
+			Comment:  models.Ptr(comment),
+			Matchers: matchers,
+			StartsAt: models.Ptr(strfmt.DateTime(diffBase.Add(startsAt))),
+			EndsAt:   models.Ptr(strfmt.DateTime(diffBase.Add(endsAt))),
+		},
+	}
+}
+
+func TestSilenceDiff(t *testing.T) {
+	alertname := testDiffMatcher("alertname", "Down", true, false)
+	envProd := testDiffMatcher("env", "prod", true, false)
+	envRegex := testDiffMatcher("env", "prod|staging", true, true)
+	team := testDiffMatcher("team", "db", false, false)
+
+	tests := []struct {
+		name     string
+		previous *almodels.GettableSilence
+		current  *almodels.GettableSilence
+		want     string
+	}{
+		{
+			name:     "no-changes",
+			previous: testDiffSilence("maintenance", 0, time.Hour, alertname),
+			current:  testDiffSilence("maintenance", 0, time.Hour, alertname),
+			want:     "",
+		},
+		{
+			name:     "added-matcher",
+			previous: testDiffSilence("maintenance", 0, time.Hour, alertname),
+			current:  testDiffSilence("maintenance", 0, time.Hour, alertname, team),
+			want:     "**Matchers:**\n```diff\n+ team!=\"db\"\n```",
+		},
+		{
+			name:     "removed-matcher",
+			previous: testDiffSilence("maintenance", 0, time.Hour, alertname, envProd),
+			current:  testDiffSilence("maintenance", 0, time.Hour, alertname),
+			want:     "**Matchers:**\n```diff\n- env=\"prod\"\n```",
+		},
+		{
+			name:     "changed-matcher",
+			previous: testDiffSilence("maintenance", 0, time.Hour, alertname, envProd),
+			current:  testDiffSilence("maintenance", 0, time.Hour, alertname, envRegex),
+			want:     "**Matchers:**\n```diff\n- env=\"prod\"\n+ env=~\"prod|staging\"\n```",
+		},
+		{
+			name:     "comment",
+			previous: testDiffSilence("maintenance", 0, time.Hour, alertname),
+			current:  testDiffSilence("extended maintenance", 0, time.Hour, alertname),
+			want:     "**Comment:** ~~maintenance~~ → extended maintenance",
+		},
+		{
+			name:     "extended",
+			previous: testDiffSilence("maintenance", 0, time.Hour, alertname),
+			current:  testDiffSilence("maintenance", 0, 2*time.Hour, alertname),
+			want: fmt.Sprintf(
+				"**Ends:** <t:%d:f> → <t:%d:f> (+1h0m0s)",
+				diffBase.Add(time.Hour).Unix(), diffBase.Add(2*time.Hour).Unix(),
+			),
+		},
+		{
+			name:     "started-earlier",
+			previous: testDiffSilence("maintenance", 0, time.Hour, alertname),
+			current:  testDiffSilence("maintenance", -30*time.Minute, time.Hour, alertname),
+			want: fmt.Sprintf(
+				"**Starts:** <t:%d:f> → <t:%d:f> (-30m0s)",
+				diffBase.Unix(), diffBase.Add(-30*time.Minute).Unix(),
+			),
+		},
+		{
+			name:     "shift-below-threshold",
+			previous: testDiffSilence("maintenance", 0, time.Hour, alertname),
+			current:  testDiffSilence("maintenance", 20*time.Second, time.Hour+20*time.Second, alertname),
+			want:     "",
+		},
+		{
+			name:     "everything",
+			previous: testDiffSilence("a", 0, time.Hour, alertname),
+			current:  testDiffSilence("b", 0, 2*time.Hour, team),
+			want: "**Matchers:**\n```diff\n- alertname=\"Down\"\n+ team!=\"db\"\n```\n" +
+				"**Comment:** ~~a~~ → b\n" +
+				fmt.Sprintf(
+					"**Ends:** <t:%d:f> → <t:%d:f> (+1h0m0s)",
+					diffBase.Add(time.Hour).Unix(), diffBase.Add(2*time.Hour).Unix(),
+				),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := silenceDiff(tt.previous, tt.current, 1024); got != tt.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSilenceDiffLength(t *testing.T) {
+	var previous, current []*almodels.Matcher
+	for i := 0; i < 100; i++ {
+		previous = append(previous, testDiffMatcher(fmt.Sprintf("label_%d", i), "old-value", true, false))
+		current = append(current, testDiffMatcher(fmt.Sprintf("label_%d", i), "new-value", true, false))
+	}
+
+	got := silenceDiff(
+		testDiffSilence(strings.Repeat("a", 500), 0, time.Hour, previous...),
+		testDiffSilence(strings.Repeat("b", 500), 0, 2*time.Hour, current...),
+		1024,
+	)
+
+	if length := len([]rune(got)); length > 1024 {
+		t.Fatalf("got diff of length %d, want at most 1024", length)
+	}
+
+	// The code block must always be closed, with the omitted lines mentioned.
+	if strings.Count(got, "```") != 2 {
+		t.Fatalf("expected a closed code block, got:\n%s", got)
+	}
+
+	if !strings.Contains(got, "more\n```") {
+		t.Fatalf("expected omitted matchers to be mentioned, got:\n%s", got)
+	}
+
+	if !strings.Contains(got, "**Ends:**") {
+		t.Fatalf("expected other changes to be kept, got:\n%s", got)
+	}
+}
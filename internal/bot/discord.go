@@ -0,0 +1,88 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/andersfylling/disgord"
+)
+
+const discordAPIURL = "https://discord.com/api/v10"
+
+// interactionRequest sends a request to one of Discord's interaction endpoints,
+// which are authenticated using the interaction token, rather than the bot token.
+// This is used for functionality which disgord doesn't support.
+func (b *Bot) interactionRequest(ctx context.Context, method, path string, body, result any) error {
+	var reqBody io.Reader
+
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		reqBody = bytes.NewReader(buf)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, httpRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, discordAPIURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:gomnd
+		return fmt.Errorf("discord returned status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// originalResponse returns the message which was sent in response to the
+// interaction.
+func (b *Bot) originalResponse(h *disgord.InteractionCreate) (*disgord.Message, error) {
+	msg := &disgord.Message{}
+
+	err := b.interactionRequest(
+		b.ctx,
+		http.MethodGet,
+		fmt.Sprintf("/webhooks/%d/%s/messages/@original", h.ApplicationID, h.Token),
+		nil,
+		msg,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Returned messages don't include the guild ID.
+	msg.GuildID = h.GuildID
+	if msg.ChannelID.IsZero() {
+		msg.ChannelID = h.ChannelID
+	}
+
+	return msg, nil
+}
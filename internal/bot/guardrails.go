@@ -0,0 +1,116 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/lrstanley/discord-alertmanager/internal/models"
+	"golang.org/x/exp/slices"
+)
+
+// guardrailError is returned when a silence violates one of the configured
+// guardrails. The rule matches the name of the flag which configures it.
+type guardrailError struct {
+	rule   string
+	reason string
+}
+
+func (e *guardrailError) Error() string {
+	return e.reason
+}
+
+// checkGuardrails validates the (already parsed) silence configuration against the
+// configured guardrails, excluding those which require fetching alerts.
+func (m *addConfig) checkGuardrails(rails models.ConfigGuardrails) error {
+	if len(rails.RequiredLabels) > 0 {
+		var found bool
+		for _, matcher := range m.matchersCompiled {
+			if (matcher.Type == alertmanager.MatchEqual || matcher.Type == alertmanager.MatchRegexp) &&
+				!matcher.IsMatchAll() && slices.Contains(rails.RequiredLabels, matcher.Name) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return &guardrailError{
+				rule: "required-labels",
+				reason: fmt.Sprintf(
+					"Silences must include a matcher for at least one of the following labels: `%s`.",
+					strings.Join(rails.RequiredLabels, "`, `"),
+				),
+			}
+		}
+	}
+
+	if rails.DenyMatchAll {
+		for _, matcher := range m.matchersCompiled {
+			if matcher.IsMatchAll() {
+				return &guardrailError{
+					rule: "deny-match-all",
+					reason: fmt.Sprintf(
+						"Matcher `%s` matches any value, which would silence far more than intended. Use a more specific value instead.",
+						matcher.String(),
+					),
+				}
+			}
+		}
+	}
+
+	if rails.MaxDuration > 0 {
+		// Only count the remaining duration for silences which have already started
+		// (e.g. when extending a silence).
+		start := m.startsAtParsed
+		if start.Before(time.Now()) {
+			start = time.Now()
+		}
+
+		if duration := m.endsAtParsed.Sub(start); duration > rails.MaxDuration {
+			return &guardrailError{
+				rule: "max-duration",
+				reason: fmt.Sprintf(
+					"Silence duration of %s exceeds the maximum allowed duration of %s.",
+					duration.Round(time.Minute), rails.MaxDuration,
+				),
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkMatchedGuardrails validates the number of alerts the silence currently
+// matches against the configured guardrails.
+func (m *addConfig) checkMatchedGuardrails(rails models.ConfigGuardrails, matched int) error {
+	if rails.MaxMatchedAlerts > 0 && matched > rails.MaxMatchedAlerts {
+		return &guardrailError{
+			rule: "max-matched-alerts",
+			reason: fmt.Sprintf(
+				"Silence currently matches %d alerts, which exceeds the maximum of %d. Use more specific matchers, or multiple silences.",
+				matched, rails.MaxMatchedAlerts,
+			),
+		}
+	}
+
+	return nil
+}
+
+// responseValidationError responds with an error explaining why the silence
+// configuration was rejected, including which guardrail was violated, if any.
+func (b *Bot) responseValidationError(s disgord.Session, h *disgord.InteractionCreate, err error) {
+	var gerr *guardrailError
+	if errors.As(err, &gerr) {
+		b.responseError(s, h, fmt.Sprintf("Silence rejected by guardrail: %s", gerr.rule), err)
+		return
+	}
+
+	b.responseError(s, h, "Invalid silence configuration provided", err)
+}
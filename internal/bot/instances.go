@@ -0,0 +1,193 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+	"github.com/apex/log"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/lrstanley/discord-alertmanager/internal/store"
+)
+
+// instanceOption is added to silence and alert subcommands when multiple
+// instances are configured.
+var instanceOption = &disgord.ApplicationCommandOption{
+	Name:         "instance",
+	Description:  "Alertmanager instance to use (defaults to the channel default)",
+	Type:         disgord.OptionTypeString,
+	Required:     false,
+	Autocomplete: true,
+}
+
+// withInstanceOption returns a copy of the subcommands, with the instance option
+// appended to each.
+func withInstanceOption(subcommands []*disgord.ApplicationCommandOption) []*disgord.ApplicationCommandOption {
+	out := make([]*disgord.ApplicationCommandOption, 0, len(subcommands))
+
+	for _, sub := range subcommands {
+		c := *sub
+		c.Options = append(append([]*disgord.ApplicationCommandOption{}, sub.Options...), instanceOption)
+		out = append(out, &c)
+	}
+
+	return out
+}
+
+// splitCustomID splits a custom ID in the format "<id>[:<instance>][/<arg>...]"
+// into its parts.
+func splitCustomID(raw string) (customID, instance string, args []string) {
+	customID = raw
+
+	// Custom IDs that have a specific prefix, and provided arguments.
+	if i := strings.Index(customID, "/"); i != -1 {
+		args = strings.Split(customID[i+1:], "/")
+		customID = customID[:i]
+	}
+
+	if i := strings.Index(customID, ":"); i != -1 {
+		instance = customID[i+1:]
+		customID = customID[:i]
+	}
+
+	return customID, instance, args
+}
+
+// customIDFor returns a custom ID for a component or modal acting on a resource
+// in the provided instance. The instance is only included when more than one is
+// configured, which keeps custom IDs unchanged for single instance setups.
+func (b *Bot) customIDFor(al *alertmanager.Client, customID string, args ...string) string {
+	if b.instances.Multiple() {
+		customID += ":" + al.Name
+	}
+
+	if len(args) > 0 {
+		customID += "/" + strings.Join(args, "/")
+	}
+
+	return customID
+}
+
+// messagesInstance returns the instance that silences shown in the messages
+// link to, if any.
+func (b *Bot) messagesInstance(messages ...*disgord.Message) (*alertmanager.Client, bool) {
+	for _, msg := range messages {
+		if msg == nil {
+			continue
+		}
+
+		for _, embed := range msg.Embeds {
+			if al, ok := b.instances.ForURL(embed.URL); ok {
+				return al, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// channelInstance returns the default instance for the channel, falling back to
+// the default instance.
+func (b *Bot) channelInstance(channelID disgord.Snowflake) *alertmanager.Client {
+	settings, err := b.store.ChannelSettings(b.ctx, channelID)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			b.logger.WithError(err).WithField("channel_id", channelID).Warn("failed to fetch channel settings")
+		}
+
+		return b.instances.Default()
+	}
+
+	if settings.Instance == "" {
+		return b.instances.Default()
+	}
+
+	al, ok := b.instances.Get(settings.Instance)
+	if !ok {
+		b.logger.WithFields(log.Fields{
+			"channel_id": channelID,
+			"instance":   settings.Instance,
+		}).Warn("channel default instance is no longer configured, using default instance")
+
+		return b.instances.Default()
+	}
+
+	return al
+}
+
+// resolveInstance returns the Alertmanager instance the interaction applies to,
+// which is (in order of precedence):
+//   - the instance in the custom ID, for buttons and modals.
+//   - the "instance" command option.
+//   - the instance of the silence shown in the message being acted upon.
+//   - the channel default instance.
+//   - the default instance.
+func (b *Bot) resolveInstance(h *disgord.InteractionCreate) (*alertmanager.Client, error) {
+	if !b.instances.Multiple() {
+		return b.instances.Default(), nil
+	}
+
+	var name string
+	var messages []*disgord.Message
+
+	if h.Data != nil {
+		_, name, _ = splitCustomID(h.Data.CustomID)
+
+		if name == "" && (h.Type == disgord.InteractionApplicationCommand ||
+			h.Type == disgord.InteractionApplicationCommandAutocomplete) {
+			name, _ = optionsHasChild[string](h.Data.Options, "instance")
+		}
+
+		if h.Data.Resolved != nil {
+			for _, msg := range h.Data.Resolved.Messages {
+				messages = append(messages, msg)
+			}
+		}
+	}
+
+	if name != "" {
+		al, ok := b.instances.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("Unknown Alertmanager instance %q. Use `/alertmanager instances` to list available instances.", name) //nolint:revive,stylecheck
+		}
+
+		return al, nil
+	}
+
+	if al, ok := b.messagesInstance(append(messages, h.Message)...); ok {
+		return al, nil
+	}
+
+	return b.channelInstance(h.ChannelID), nil
+}
+
+// instance returns the Alertmanager instance the interaction applies to. The
+// instance is validated when the interaction is received, so this always returns
+// a usable instance.
+func (b *Bot) instance(h *disgord.InteractionCreate) *alertmanager.Client {
+	al, err := b.resolveInstance(h)
+	if err != nil {
+		return b.instances.Default()
+	}
+
+	return al
+}
+
+// instanceField returns an embed field showing which instance a resource lives
+// in, or nil if only one instance is configured.
+func (b *Bot) instanceField(al *alertmanager.Client) *disgord.EmbedField {
+	if !b.instances.Multiple() {
+		return nil
+	}
+
+	return &disgord.EmbedField{
+		Name:   ":globe_with_meridians: Instance",
+		Value:  fmt.Sprintf("[%s](%s)", al.Name, al.URL()),
+		Inline: true,
+	}
+}
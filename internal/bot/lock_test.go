@@ -0,0 +1,70 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	var m keyedMutex
+
+	unlock := m.Lock("a")
+
+	// Other keys aren't blocked.
+	done := make(chan struct{})
+	go func() {
+		m.Lock("b")()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("locking another key blocked")
+	}
+
+	// The same key is.
+	locked := make(chan struct{})
+	go func() {
+		m.Lock("a")()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("locked the same key twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	<-locked
+
+	var wg sync.WaitGroup
+	counter := 0
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			defer m.Lock("c")()
+			counter++
+		}()
+	}
+
+	wg.Wait()
+
+	if counter != 50 {
+		t.Fatalf("got counter %d, want 50", counter)
+	}
+
+	if len(m.locks) != 0 {
+		t.Fatalf("got %d tracked keys after unlocking, want 0", len(m.locks))
+	}
+}
@@ -0,0 +1,334 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	actionAll    = "*"
+	actionView   = "view"
+	actionAdd    = "add"
+	actionEdit   = "edit"
+	actionRemove = "remove"
+	// actionAlert is required to send alerts to Alertmanager (e.g. test
+	// notifications, or firing/resolving alerts manually).
+	actionAlert = "alert"
+
+	// actionAdmin is required to change bot settings, and is limited to guild
+	// administrators when RBAC rules are configured.
+	actionAdmin = "admin"
+)
+
+var rbacActions = []string{actionAll, actionView, actionAdd, actionEdit, actionRemove, actionAlert}
+
+// rbacRule grants a Discord role access to a set of actions. If constraints are
+// provided, silences managed through the rule must include all of the constraint
+// matchers (e.g. a role may only manage silences with team="db").
+type rbacRule struct {
+	roleID      disgord.Snowflake
+	actions     []string
+	constraints alertmanager.Matchers
+}
+
+func (r *rbacRule) allows(action string) bool {
+	return slices.Contains(r.actions, actionAll) || slices.Contains(r.actions, action)
+}
+
+// satisfiedBy returns true if all of the rule's constraints are present in the
+// provided matchers.
+func (r *rbacRule) satisfiedBy(matchers []*almodels.Matcher) bool {
+	compiled, err := alertmanager.CompileMatchers(matchers)
+	if err != nil {
+		return false
+	}
+
+	for _, c := range r.constraints {
+		if !slices.ContainsFunc(compiled, func(m *alertmanager.Matcher) bool {
+			return m.Type == c.Type && m.Name == c.Name && m.Value == c.Value
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *rbacRule) String() string {
+	var constraints []string
+	for _, c := range r.constraints {
+		constraints = append(constraints, c.String())
+	}
+
+	return strings.Join(constraints, ", ")
+}
+
+// parseRBACRules parses rules in the format "<role-id>:<action>[,<action>...][:<constraints>]",
+// where constraints use the same syntax as silence matchers.
+func parseRBACRules(input []string) (rules []*rbacRule, err error) {
+	for _, raw := range input {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		parts := strings.SplitN(raw, ":", 3) //nolint:gomnd
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid rbac rule %q: expected <role-id>:<actions>[:<constraints>]", raw)
+		}
+
+		rule := &rbacRule{roleID: disgord.ParseSnowflakeString(strings.TrimSpace(parts[0]))}
+		if rule.roleID.IsZero() {
+			return nil, fmt.Errorf("invalid rbac rule %q: invalid role id", raw)
+		}
+
+		for _, action := range strings.Split(parts[1], ",") {
+			action = strings.ToLower(strings.TrimSpace(action))
+			if !slices.Contains(rbacActions, action) {
+				return nil, fmt.Errorf("invalid rbac rule %q: unknown action %q (valid: %s)", raw, action, strings.Join(rbacActions, ", "))
+			}
+
+			rule.actions = append(rule.actions, action)
+		}
+
+		if len(parts) == 3 && strings.TrimSpace(parts[2]) != "" { //nolint:gomnd
+			var matchers []*almodels.Matcher
+
+			matchers, err = alertmanager.ParseLabels(parts[2], false)
+			if err == nil {
+				rule.constraints, err = alertmanager.CompileMatchers(matchers)
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid rbac rule %q: invalid constraints: %w", raw, err)
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// interactionAction returns the action the interaction requires. An empty string
+// means no specific permissions are required (e.g. confirming a pending silence,
+// which was already authorized when it was requested).
+func interactionAction(h *disgord.InteractionCreate, customID string) string {
+	switch customID {
+	case "modal-add", "silence-clone", "alert-group-silence":
+		return actionAdd
+	case "modal-edit", "silence-extend", "silence-edit":
+		return actionEdit
+	case "silence-expire", "silence-expire-confirm":
+		return actionRemove
+	case "modal-alert-fire", "alert-resolve":
+		return actionAlert
+	case "silence-confirm", "silence-cancel", "silence-expire-cancel":
+		return ""
+	}
+
+	switch h.Data.Name {
+	case "silence alert":
+		return actionAdd
+	case "edit silence":
+		return actionEdit
+	case "remove silence":
+		return actionRemove
+	case "silences":
+		switch h.Data.Options[0].Name {
+		case "add":
+			return actionAdd
+		case "edit":
+			return actionEdit
+		case "remove":
+			return actionRemove
+		}
+	case "alerts":
+		switch h.Data.Options[0].Name {
+		case "fire", "resolve":
+			return actionAlert
+		}
+	case "alertmanager":
+		switch h.Data.Options[0].Name {
+		case "set-default":
+			return actionAdmin
+		case "receiver-test":
+			return actionAlert
+		}
+	case "audit":
+		return actionAdmin
+	}
+
+	return actionView
+}
+
+// isAdmin returns true if the member has the administrator permission, in which
+// case RBAC rules are bypassed.
+func (b *Bot) isAdmin(s disgord.Session, h *disgord.InteractionCreate) bool {
+	perms, err := h.Member.GetPermissions(b.ctx, s)
+	if err != nil {
+		b.logger.WithError(err).Warn("failed to fetch member permissions")
+		return false
+	}
+
+	return perms.Contains(disgord.PermissionAdministrator)
+}
+
+// rulesFor returns the RBAC rules which apply to the interaction member, and
+// allow the provided action.
+func (b *Bot) rulesFor(h *disgord.InteractionCreate, action string) (rules []*rbacRule) {
+	for _, rule := range b.rbac {
+		if rule.allows(action) && slices.Contains(h.Member.Roles, rule.roleID) {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// isReminderCreator returns true if the interaction is a reminder button (extend
+// or dismiss) pressed by the creator of the silence.
+func (b *Bot) isReminderCreator(h *disgord.InteractionCreate) bool {
+	if h.Member == nil || h.Member.User == nil {
+		return false
+	}
+
+	customID, instance, args := splitCustomID(h.Data.CustomID)
+	if (customID != "silence-extend" && customID != "reminder-dismiss") || len(args) < 1 {
+		return false
+	}
+
+	meta, err := b.store.Silence(b.ctx, args[0])
+	if err != nil {
+		return false
+	}
+
+	return meta.UserID == h.Member.User.ID && (instance == "" || meta.Instance == instance)
+}
+
+// allowed returns true if the interaction member is allowed to perform the action.
+// If no RBAC rules are configured, Discord's command permissions are relied upon
+// instead, except for buttons.
+func (b *Bot) allowed(s disgord.Session, h *disgord.InteractionCreate, action string) bool {
+	if action == "" {
+		return true
+	}
+
+	if len(b.rbac) == 0 {
+		// Discord's command permissions don't apply to buttons, which anyone who
+		// can see the message can press, so only allow administrators (or the
+		// creator, for reminders) to use buttons which change silences/alerts.
+		if h.Type == disgord.InteractionMessageComponent && action != actionView {
+			return b.isReminderCreator(h) || b.isAdmin(s, h)
+		}
+
+		return true
+	}
+
+	// Direct messages have no guild (and thus no roles), so creators are allowed
+	// to act on reminders for their own silences from there.
+	if h.GuildID.IsZero() && b.isReminderCreator(h) {
+		return true
+	}
+
+	if action == actionAdmin {
+		return b.isAdmin(s, h)
+	}
+
+	return len(b.rulesFor(h, action)) > 0 || b.isAdmin(s, h)
+}
+
+// authorize checks if the interaction member is allowed to perform the action,
+// responding with an error if not.
+func (b *Bot) authorize(s disgord.Session, h *disgord.InteractionCreate, action string) bool {
+	if b.allowed(s, h, action) {
+		return true
+	}
+
+	err := fmt.Errorf("You do not have permission to %s silences/alerts.", action) //nolint:revive,stylecheck
+	switch action {
+	case actionAdmin:
+		err = errors.New("You do not have permission to change bot settings.") //nolint:revive,stylecheck
+	case actionAlert:
+		err = errors.New("You do not have permission to send alerts.") //nolint:revive,stylecheck
+	}
+
+	b.responseError(s, h, "Permission denied", err)
+	return false
+}
+
+// authorizeMatchers checks if the interaction member is allowed to perform the
+// action on a silence with the provided matchers, taking label constraints into
+// account, responding with an error if not.
+func (b *Bot) authorizeMatchers(s disgord.Session, h *disgord.InteractionCreate, action string, matchers []*almodels.Matcher) bool {
+	if len(b.rbac) == 0 || (h.GuildID.IsZero() && b.isReminderCreator(h)) {
+		return true
+	}
+
+	rules := b.rulesFor(h, action)
+
+	var constraints []string
+	for _, rule := range rules {
+		if rule.satisfiedBy(matchers) {
+			return true
+		}
+
+		constraints = append(constraints, rule.String())
+	}
+
+	if b.isAdmin(s, h) {
+		return true
+	}
+
+	err := errors.New("You do not have permission to manage silences with these matchers.") //nolint:revive,stylecheck
+	switch {
+	case action == actionAlert && len(constraints) > 0:
+		err = fmt.Errorf(
+			"Your roles only allow you to send alerts which include the following labels: `%s`", //nolint:revive,stylecheck
+			strings.Join(constraints, "` or `"),
+		)
+	case action == actionAlert:
+		err = errors.New("You do not have permission to send alerts with these labels.") //nolint:revive,stylecheck
+	case len(constraints) > 0:
+		err = fmt.Errorf(
+			"Your roles only allow you to %s silences which include the following matchers: `%s`", //nolint:revive,stylecheck
+			action, strings.Join(constraints, "` or `"),
+		)
+	}
+
+	b.responseError(s, h, "Permission denied", err)
+	return false
+}
+
+// applicationCommands returns the commands to register. If RBAC rules are
+// configured, commands are made available to everyone, and permissions are
+// enforced by the bot instead. If multiple Alertmanager instances are configured,
+// silence and alert subcommands get an instance option.
+func (b *Bot) applicationCommands() []*disgord.CreateApplicationCommand {
+	out := make([]*disgord.CreateApplicationCommand, 0, len(commands))
+	for _, cmd := range commands {
+		c := *cmd
+
+		if len(b.rbac) > 0 {
+			c.DefaultMemberPermissions = nil
+		}
+
+		if b.instances.Multiple() && (c.Name == "silences" || c.Name == "alerts") {
+			c.Options = withInstanceOption(c.Options)
+		}
+
+		out = append(out, &c)
+	}
+
+	return out
+}
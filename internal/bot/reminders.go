@@ -0,0 +1,248 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/apex/log"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/lrstanley/discord-alertmanager/internal/store"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	almodels "github.com/prometheus/alertmanager/api/v2/models"
+)
+
+// runReminders periodically checks for silences created by Discord users which
+// are about to expire, and reminds their creator.
+func (b *Bot) runReminders(ctx context.Context) {
+	ticker := time.NewTicker(b.config.Reminders.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.checkReminders()
+		}
+	}
+}
+
+func (b *Bot) checkReminders() {
+	known := make(map[string]bool)
+	complete := true
+
+	for _, al := range b.instances.List() {
+		if !b.checkInstanceReminders(al, known) {
+			complete = false
+		}
+	}
+
+	// Don't forget about silences if an instance couldn't be checked, as they
+	// would appear to no longer exist.
+	if !complete {
+		return
+	}
+
+	// Forget about silences Alertmanager no longer knows about (expired silences
+	// are kept for a while by Alertmanager, so they are kept here too).
+	metas, err := b.store.Silences(b.ctx)
+	if err != nil {
+		b.logger.WithError(err).Error("failed to fetch silence metadata")
+		return
+	}
+
+	for _, meta := range metas {
+		if known[meta.ID] {
+			continue
+		}
+
+		if err = b.store.DeleteSilence(b.ctx, meta.ID); err != nil {
+			b.logger.WithError(err).Error("failed to delete silence metadata")
+		}
+	}
+}
+
+// checkInstanceReminders sends reminders for silences in the instance, adding
+// all silences it knows about to known. Returns false if the silences could not
+// be fetched.
+func (b *Bot) checkInstanceReminders(al *alertmanager.Client, known map[string]bool) bool {
+	params := &silence.GetSilencesParams{}
+	params.SetContext(b.ctx)
+	params.SetTimeout(httpRequestTimeout)
+
+	silences, err := al.Silence.GetSilences(params, al.HandleAuth)
+	if err != nil {
+		b.logger.WithError(err).WithField("instance", al.Name).Error("failed to fetch silences for reminders")
+		return false
+	}
+
+	for _, alertSilence := range silences.Payload {
+		known[*alertSilence.ID] = true
+
+		if *alertSilence.Status.State != "active" {
+			continue
+		}
+
+		match := reDiscordUsername.FindStringSubmatch(*alertSilence.CreatedBy)
+		if len(match) != 3 { //nolint:gomnd
+			continue
+		}
+
+		userID := disgord.ParseSnowflakeString(match[1])
+		if userID.IsZero() {
+			continue
+		}
+
+		endsAt := time.Time(*alertSilence.EndsAt)
+		if !shouldRemind(time.Time(*alertSilence.StartsAt), endsAt, time.Now(), b.config.Reminders.Before) {
+			continue
+		}
+
+		meta, err := b.store.Silence(b.ctx, *alertSilence.ID)
+		if err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				b.logger.WithError(err).Error("failed to fetch silence metadata")
+				continue
+			}
+
+			// Created outside of the bot, or before it had a store.
+			meta = &store.Silence{ID: *alertSilence.ID, UserID: userID, Instance: al.Name}
+		}
+
+		// Silences which are extended in place are reminded again.
+		if meta.RemindedFor.Equal(endsAt) {
+			continue
+		}
+
+		b.sendReminder(al, userID, meta.ChannelID, alertSilence)
+
+		meta.RemindedFor = endsAt
+		if err = b.store.SetSilence(b.ctx, meta); err != nil {
+			b.logger.WithError(err).Error("failed to update silence metadata")
+		}
+	}
+
+	return true
+}
+
+// shouldRemind returns true if the creator of a silence should be reminded that
+// it's about to end. Silences which don't last longer than before would be
+// reminded about as soon as they're created, so they're reminded halfway through
+// instead.
+func shouldRemind(startsAt, endsAt, now time.Time, before time.Duration) bool {
+	if duration := endsAt.Sub(startsAt); duration <= before {
+		before = duration / 2 //nolint:gomnd
+	}
+
+	return endsAt.Sub(now) <= before
+}
+
+// sendReminder pings the silence creator in the channel the silence was created
+// from, falling back to a direct message if the channel isn't known.
+func (b *Bot) sendReminder(
+	al *alertmanager.Client,
+	userID, channelID disgord.Snowflake,
+	alertSilence *almodels.GettableSilence,
+) {
+	logger := b.logger.WithFields(log.Fields{
+		"instance":   al.Name,
+		"silence_id": *alertSilence.ID,
+		"user_id":    userID,
+	})
+
+	if channelID.IsZero() {
+		channel, err := b.client.User(userID).WithContext(b.ctx).CreateDM()
+		if err != nil {
+			logger.WithError(err).Error("failed to create direct message channel for reminder")
+			return
+		}
+
+		channelID = channel.ID
+	}
+
+	silenceEmbed := b.silenceEmbed(b.client, al, alertSilence)
+	silenceEmbed.Color = colorWarning
+	silenceEmbed.Title = fmt.Sprintf("Silence expiring soon: %s", *alertSilence.ID)
+	silenceEmbed.Description = fmt.Sprintf(
+		"This silence expires <t:%d:R>. Extend it, or let it expire?\n%s",
+		time.Time(*alertSilence.EndsAt).Unix(),
+		silenceEmbed.Description,
+	)
+
+	_, err := b.client.Channel(channelID).WithContext(b.ctx).CreateMessage(&disgord.CreateMessage{
+		Content:         fmt.Sprintf("<@%d>", userID),
+		AllowedMentions: &disgord.AllowedMentions{Users: []disgord.Snowflake{userID}},
+		Embeds:          []*disgord.Embed{silenceEmbed},
+		Components:      b.reminderComponents(al, *alertSilence.ID),
+	})
+	if err != nil {
+		logger.WithField("channel_id", channelID).WithError(err).Error("failed to send reminder")
+	}
+}
+
+func (b *Bot) reminderComponents(al *alertmanager.Client, id string) []*disgord.MessageComponent {
+	return []*disgord.MessageComponent{{
+		Type: disgord.MessageComponentActionRow,
+		Components: []*disgord.MessageComponent{
+			{
+				Type:     disgord.MessageComponentButton,
+				Style:    disgord.Primary,
+				Label:    "Extend 1h",
+				CustomID: b.customIDFor(al, "silence-extend", id, "1h"),
+			},
+			{
+				Type:     disgord.MessageComponentButton,
+				Style:    disgord.Primary,
+				Label:    "Extend 4h",
+				CustomID: b.customIDFor(al, "silence-extend", id, "4h"),
+			},
+			{
+				Type:     disgord.MessageComponentButton,
+				Style:    disgord.Secondary,
+				Label:    "Let expire",
+				CustomID: b.customIDFor(al, "reminder-dismiss", id),
+			},
+		},
+	}}
+}
+
+func (b *Bot) reminderDismissFromButton(s disgord.Session, h *disgord.InteractionCreate, _ string, args []string) {
+	if len(args) < 1 {
+		return
+	}
+
+	al := b.instance(h)
+
+	alertSilence, ok := b.getSilence(s, h, al, args[0])
+	if !ok {
+		return
+	}
+
+	silenceEmbed := b.silenceEmbed(s, al, alertSilence)
+	silenceEmbed.Title = fmt.Sprintf("Silence will expire: %s", *alertSilence.ID)
+	silenceEmbed.Description = fmt.Sprintf(
+		"<@%d> chose to let this silence expire <t:%d:R>.\n%s",
+		h.Member.User.ID,
+		time.Time(*alertSilence.EndsAt).Unix(),
+		silenceEmbed.Description,
+	)
+
+	err := s.SendInteractionResponse(b.ctx, h, &disgord.CreateInteractionResponse{
+		Type: disgord.InteractionCallbackUpdateMessage,
+		Data: &disgord.CreateInteractionResponseData{
+			AllowedMentions: &disgord.AllowedMentions{Parse: []string{}},
+			Embeds:          []*disgord.Embed{silenceEmbed},
+			Components:      []*disgord.MessageComponent{},
+		},
+	})
+	if err != nil {
+		b.logger.WithError(err).Error("failed to respond to interaction")
+	}
+}
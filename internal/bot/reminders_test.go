@@ -0,0 +1,40 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShouldRemind(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	before := 30 * time.Minute
+
+	tests := []struct {
+		name     string
+		startsAt time.Duration // Relative to now.
+		endsAt   time.Duration // Relative to now.
+		want     bool
+	}{
+		{name: "long-not-yet", startsAt: -time.Hour, endsAt: time.Hour, want: false},
+		{name: "long-within-before", startsAt: -time.Hour, endsAt: 20 * time.Minute, want: true},
+		{name: "long-at-before", startsAt: -time.Hour, endsAt: before, want: true},
+		{name: "short-just-created", startsAt: 0, endsAt: 20 * time.Minute, want: false},
+		{name: "short-before-halfway", startsAt: -5 * time.Minute, endsAt: 15 * time.Minute, want: false},
+		{name: "short-halfway", startsAt: -10 * time.Minute, endsAt: 10 * time.Minute, want: true},
+		{name: "equal-to-before-just-created", startsAt: 0, endsAt: before, want: false},
+		{name: "equal-to-before-halfway", startsAt: -15 * time.Minute, endsAt: 15 * time.Minute, want: true},
+		{name: "ended", startsAt: -time.Hour, endsAt: -time.Minute, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldRemind(now.Add(tt.startsAt), now.Add(tt.endsAt), now, before); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/apex/log"
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+	"github.com/lrstanley/discord-alertmanager/internal/store"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	webhookPath        = "/webhook"
+	webhookMaxBodySize = 5 << 20 // 5MB.
+	maxMessageEmbeds   = 10
+	maxEmbedTitle      = 256
+	maxEmbedDesc       = 4096
+
+	// alertGroupTTL is how long we remember the message for an alert group that
+	// hasn't received any notifications, before posting a new message instead.
+	alertGroupTTL           = 7 * 24 * time.Hour
+	alertGroupPruneInterval = 1 * time.Hour
+)
+
+// alertGroupKey returns the key which tracks the message for an alert group, so
+// notifications for the same group can update the existing message.
+func alertGroupKey(channelID disgord.Snowflake, groupKey string) string {
+	return fmt.Sprintf("%d/%s", channelID, groupKey)
+}
+
+// truncate truncates the input to the provided length (in runes), adding an ellipsis
+// if the input was truncated.
+func truncate(input string, length int) string {
+	runes := []rune(input)
+	if len(runes) <= length {
+		return input
+	}
+
+	return string(runes[:length-1]) + "…"
+}
+
+// alertDescription generates a description for an alert, which mimics the default
+// Alertmanager notification template, allowing the "silence alert" message command
+// to parse labels out of it.
+func alertDescription(status string, labels, annotations map[string]string, source string) string {
+	var sb strings.Builder
+
+	if status == alertmanager.StatusResolved {
+		sb.WriteString("Alerts Resolved:\n")
+	} else {
+		sb.WriteString("Alerts Firing:\n")
+	}
+
+	sb.WriteString("Labels:\n")
+	keys := maps.Keys(labels)
+	slices.Sort(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, " - %s = %s\n", k, labels[k])
+	}
+
+	sb.WriteString("Annotations:\n")
+	keys = maps.Keys(annotations)
+	slices.Sort(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, " - %s = %s\n", k, annotations[k])
+	}
+
+	fmt.Fprintf(&sb, "Source: %s\n", source)
+
+	return truncate(sb.String(), maxEmbedDesc)
+}
+
+// alertTitle returns a title for an alert, based on its name and status.
+func alertTitle(status string, labels map[string]string) string {
+	name := labels["alertname"]
+	if name == "" {
+		name = "unknown alert"
+	}
+
+	return truncate(fmt.Sprintf("[%s] %s", strings.ToUpper(status), name), maxEmbedTitle)
+}
+
+// webhookEmbeds generates one embed per alert in the webhook message. If there
+// are more alerts than can fit in a single message (by count or total length),
+// the last embed will be a summary of the alerts that were not included.
+func (b *Bot) webhookEmbeds(msg *alertmanager.WebhookMessage) []*disgord.Embed {
+	embeds := make([]*disgord.Embed, 0, len(msg.Alerts))
+
+	for _, alert := range msg.Alerts {
+		color := colorError
+		title := alertTitle(alert.Status, alert.Labels)
+		fields := []*disgord.EmbedField{{
+			Name:   ":watch: Started",
+			Value:  fmt.Sprintf("<t:%d:R>", alert.StartsAt.Unix()),
+			Inline: true,
+		}}
+
+		if alert.IsResolved() {
+			color = colorSuccess
+			title = "~~" + truncate(title, maxEmbedTitle-4) + "~~"
+			fields = append(fields, &disgord.EmbedField{
+				Name:   ":watch: Resolved",
+				Value:  fmt.Sprintf("<t:%d:R>", alert.EndsAt.Unix()),
+				Inline: true,
+			})
+		}
+
+		embeds = append(embeds, &disgord.Embed{
+			Type:        disgord.EmbedTypeRich,
+			Color:       color,
+			Title:       title,
+			Description: alertDescription(alert.Status, alert.Labels, alert.Annotations, alert.GeneratorURL),
+			URL:         alert.GeneratorURL,
+			Fields:      fields,
+			Timestamp:   disgord.Time{Time: alert.StartsAt},
+			Footer: &disgord.EmbedFooter{
+				Text: fmt.Sprintf("Receiver: %s", msg.Receiver),
+			},
+		})
+	}
+
+	return fitEmbeds(embeds, int(msg.TruncatedAlerts), func(omitted int) *disgord.Embed {
+		return &disgord.Embed{
+			Type:        disgord.EmbedTypeRich,
+			Color:       colorWarning,
+			Title:       fmt.Sprintf("%d more alert(s) not shown", omitted),
+			Description: fmt.Sprintf("See [Alertmanager](%s) for the full list of alerts.", msg.ExternalURL),
+		}
+	})
+}
+
+// runWebhookServer starts the webhook HTTP server on the provided listener, and
+// shuts it down when the context is canceled.
+func (b *Bot) runWebhookServer(ctx context.Context, listener net.Listener) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(webhookPath, b.webhookHandler)
+	mux.HandleFunc(webhookPath+"/", b.webhookHandler)
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	b.logger.WithField("addr", listener.Addr().String()).Info("listening for alertmanager webhook notifications")
+
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		b.logger.WithError(err).Error("webhook server failed")
+	}
+}
+
+// runAlertGroupPruning periodically forgets about alert groups we haven't heard
+// from in a while.
+func (b *Bot) runAlertGroupPruning(ctx context.Context) {
+	ticker := time.NewTicker(alertGroupPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.store.PruneAlertGroups(ctx, time.Now().Add(-alertGroupTTL)); err != nil {
+				b.logger.WithError(err).Warn("failed to prune alert groups")
+			}
+		}
+	}
+}
+
+// webhookHandler receives Alertmanager webhook notifications, and posts them to
+// the configured Discord channel. The channel can be overridden per receiver, by
+// using "/webhook/<channel-id>" as the webhook URL, if the channel is allowed.
+func (b *Bot) webhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if b.config.Webhook.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(b.config.Webhook.Token)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	channelID := b.config.Webhook.ChannelID
+	if v := strings.Trim(strings.TrimPrefix(r.URL.Path, webhookPath), "/"); v != "" {
+		if v != channelID && !slices.Contains(b.config.Webhook.AllowedChannels, v) {
+			http.Error(w, "channel is not allowed", http.StatusForbidden)
+			return
+		}
+
+		channelID = v
+	}
+
+	cid := disgord.ParseSnowflakeString(channelID)
+	if cid.IsZero() {
+		http.Error(w, "no valid channel id configured or provided", http.StatusBadRequest)
+		return
+	}
+
+	msg, err := alertmanager.ParseWebhookMessage(io.LimitReader(r.Body, webhookMaxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger := b.logger.WithFields(log.Fields{
+		"channel_id": cid,
+		"receiver":   msg.Receiver,
+		"group_key":  msg.GroupKey,
+		"status":     msg.Status,
+		"alerts":     len(msg.Alerts),
+	})
+
+	if err = b.postAlertGroup(r.Context(), cid, msg); err != nil {
+		logger.WithError(err).Error("failed to post alert notification")
+		http.Error(w, "failed to post alert notification", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("posted alert notification")
+	w.WriteHeader(http.StatusOK)
+}
+
+// postAlertGroup posts the webhook message to the provided channel. If a message
+// was already posted for the same alert group, it will be updated in place instead.
+// Once the group is resolved, the message is forgotten, so the next firing
+// notification results in a new message.
+func (b *Bot) postAlertGroup(ctx context.Context, channelID disgord.Snowflake, msg *alertmanager.WebhookMessage) error {
+	embeds := b.webhookEmbeds(msg)
+	key := alertGroupKey(channelID, msg.GroupKey)
+
+	// Notifications for the same group are posted one at a time, so a group never
+	// ends up with multiple messages.
+	defer b.alertGroupLocks.Lock(key)()
+
+	group, err := b.store.AlertGroup(ctx, key)
+	ok := err == nil
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		b.logger.WithError(err).WithField("group_key", msg.GroupKey).Warn("failed to fetch alert group")
+	}
+
+	if ok {
+		_, err = b.client.Channel(channelID).Message(group.MessageID).WithContext(ctx).Update(&disgord.UpdateMessage{
+			Embeds: &embeds,
+		})
+		if err != nil {
+			// The message may have been deleted, so fallback to posting a new one.
+			// Anything else (e.g. rate limits) is returned, so Alertmanager retries.
+			var restErr *disgord.ErrRest
+			if !errors.As(err, &restErr) || restErr.HTTPCode != http.StatusNotFound {
+				return err
+			}
+
+			b.logger.WithError(err).WithField("group_key", msg.GroupKey).Warn("alert group message no longer exists")
+			ok = false
+		}
+	}
+
+	if !ok {
+		created, err := b.client.Channel(channelID).WithContext(ctx).CreateMessage(&disgord.CreateMessage{
+			Embeds: embeds,
+		})
+		if err != nil {
+			return err
+		}
+
+		group = &store.AlertGroup{Key: key, ChannelID: channelID, MessageID: created.ID}
+	}
+
+	if msg.Status == alertmanager.StatusResolved {
+		return b.store.DeleteAlertGroup(ctx, key)
+	}
+
+	group.Updated = time.Now()
+
+	return b.store.SetAlertGroup(ctx, group)
+}
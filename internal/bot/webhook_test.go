@@ -0,0 +1,88 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
+)
+
+func testWebhookMessage(alerts int, truncated uint64, description string) *alertmanager.WebhookMessage {
+	msg := &alertmanager.WebhookMessage{
+		Receiver:        "discord",
+		Status:          alertmanager.StatusFiring,
+		TruncatedAlerts: truncated,
+		ExternalURL:     "https://alertmanager.example.com",
+	}
+
+	for i := 0; i < alerts; i++ {
+		msg.Alerts = append(msg.Alerts, &alertmanager.WebhookAlert{
+			Status:      alertmanager.StatusFiring,
+			Labels:      map[string]string{"alertname": fmt.Sprintf("Alert%d", i)},
+			Annotations: map[string]string{"description": description},
+			StartsAt:    time.Now(),
+		})
+	}
+
+	return msg
+}
+
+func TestWebhookEmbeds(t *testing.T) {
+	tests := []struct {
+		name        string
+		alerts      int
+		truncated   uint64
+		description string
+		wantAlerts  int
+		wantOmitted int
+	}{
+		{name: "single", alerts: 1, wantAlerts: 1},
+		{name: "max", alerts: maxMessageEmbeds, wantAlerts: maxMessageEmbeds},
+		{name: "over-max", alerts: maxMessageEmbeds + 5, wantAlerts: maxMessageEmbeds - 1, wantOmitted: 6},
+		{name: "truncated", alerts: 3, truncated: 4, wantAlerts: 3, wantOmitted: 4},
+		{name: "max-truncated", alerts: maxMessageEmbeds, truncated: 2, wantAlerts: maxMessageEmbeds - 1, wantOmitted: 3},
+		{
+			name:        "total-length",
+			alerts:      5,
+			description: strings.Repeat("x", 2500),
+			wantAlerts:  2,
+			wantOmitted: 3,
+		},
+	}
+
+	b := &Bot{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embeds := b.webhookEmbeds(testWebhookMessage(tt.alerts, tt.truncated, tt.description))
+
+			if len(embeds) > maxMessageEmbeds {
+				t.Fatalf("got %d embeds, want at most %d", len(embeds), maxMessageEmbeds)
+			}
+
+			if length := embedsLength(embeds); length > maxMessageEmbedsLength {
+				t.Fatalf("got embeds with a total length of %d, want at most %d", length, maxMessageEmbedsLength)
+			}
+
+			alerts := len(embeds)
+			if tt.wantOmitted > 0 {
+				alerts--
+
+				want := fmt.Sprintf("%d more alert(s) not shown", tt.wantOmitted)
+				if got := embeds[len(embeds)-1].Title; got != want {
+					t.Errorf("got summary %q, want %q", got, want)
+				}
+			}
+
+			if alerts != tt.wantAlerts {
+				t.Errorf("got %d alert embeds, want %d", alerts, tt.wantAlerts)
+			}
+		})
+	}
+}
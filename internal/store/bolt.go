@@ -0,0 +1,322 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	bolt "go.etcd.io/bbolt"
+)
+
+const boltOpenTimeout = 5 * time.Second
+
+var (
+	bucketMeta     = []byte("meta")
+	bucketSilences = []byte("silences")
+	bucketGroups   = []byte("alert_groups")
+	bucketAudit    = []byte("audit")
+	bucketGuilds   = []byte("guilds")
+	bucketChannels = []byte("channels")
+
+	keyVersion = []byte("version")
+)
+
+// boltMigrations are applied in order, each in its own transaction. The index
+// (plus one) of the last applied migration is stored as the schema version. Never
+// modify or remove existing migrations, only append new ones.
+var boltMigrations = []func(tx *bolt.Tx) error{
+	// 1: initial schema.
+	func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketSilences, bucketGroups, bucketAudit, bucketGuilds} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+	// 2: per-channel settings.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketChannels)
+		return err
+	},
+}
+
+// Bolt is a Store backed by an embedded bbolt database file.
+type Bolt struct {
+	db *bolt.DB
+}
+
+var _ Store = (*Bolt)(nil)
+
+// NewBolt opens (creating if necessary) the bbolt database at the provided path,
+// and applies any pending migrations.
+func NewBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: boltOpenTimeout}) //nolint:gomnd
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store %q: %w", path, err)
+	}
+
+	s := &Bolt{db: db}
+
+	if err = s.migrate(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to migrate store %q: %w", path, err)
+	}
+
+	return s, nil
+}
+
+func (s *Bolt) migrate() error {
+	var version int
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(bucketMeta)
+		if err != nil {
+			return err
+		}
+
+		if v := meta.Get(keyVersion); v != nil {
+			version, err = strconv.Atoi(string(v))
+		}
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if version > len(boltMigrations) {
+		return fmt.Errorf("store schema version %d is newer than supported version %d", version, len(boltMigrations))
+	}
+
+	for i := version; i < len(boltMigrations); i++ {
+		err = s.db.Update(func(tx *bolt.Tx) error {
+			if err := boltMigrations[i](tx); err != nil {
+				return err
+			}
+
+			return tx.Bucket(bucketMeta).Put(keyVersion, []byte(strconv.Itoa(i+1)))
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Bolt) Close() error {
+	return s.db.Close()
+}
+
+func (s *Bolt) get(bucket, key []byte, v any) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get(key)
+		if data == nil {
+			return ErrNotFound
+		}
+
+		return json.Unmarshal(data, v)
+	})
+}
+
+func (s *Bolt) put(bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(key, data)
+	})
+}
+
+func (s *Bolt) delete(bucket, key []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete(key)
+	})
+}
+
+func (s *Bolt) Silence(_ context.Context, id string) (*Silence, error) {
+	silence := &Silence{}
+	return silence, s.get(bucketSilences, []byte(id), silence)
+}
+
+func (s *Bolt) Silences(_ context.Context) (silences []*Silence, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSilences).ForEach(func(_, v []byte) error {
+			silence := &Silence{}
+			if err := json.Unmarshal(v, silence); err != nil {
+				return err
+			}
+
+			silences = append(silences, silence)
+			return nil
+		})
+	})
+
+	return silences, err
+}
+
+func (s *Bolt) SetSilence(_ context.Context, silence *Silence) error {
+	return s.put(bucketSilences, []byte(silence.ID), silence)
+}
+
+func (s *Bolt) DeleteSilence(_ context.Context, id string) error {
+	return s.delete(bucketSilences, []byte(id))
+}
+
+func (s *Bolt) AlertGroup(_ context.Context, key string) (*AlertGroup, error) {
+	group := &AlertGroup{}
+	return group, s.get(bucketGroups, []byte(key), group)
+}
+
+func (s *Bolt) SetAlertGroup(_ context.Context, group *AlertGroup) error {
+	return s.put(bucketGroups, []byte(group.Key), group)
+}
+
+func (s *Bolt) DeleteAlertGroup(_ context.Context, key string) error {
+	return s.delete(bucketGroups, []byte(key))
+}
+
+func (s *Bolt) PruneAlertGroups(_ context.Context, before time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketGroups)
+
+		// Deleting while iterating with a cursor can skip entries, so collect
+		// the keys first.
+		var expired [][]byte
+
+		err := bucket.ForEach(func(k, v []byte) error {
+			group := &AlertGroup{}
+			if err := json.Unmarshal(v, group); err != nil {
+				return err
+			}
+
+			if group.Updated.Before(before) {
+				expired = append(expired, k)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err = bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *Bolt) AddAudit(_ context.Context, record *AuditRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketAudit)
+
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		record.ID = id
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(sequenceKey(id), data)
+	})
+}
+
+func (s *Bolt) AuditHistory(_ context.Context, guildID disgord.Snowflake, limit int) (records []*AuditRecord, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketAudit).Cursor()
+
+		// Keys are big-endian sequence numbers, so iterate backwards for newest
+		// first.
+		for k, v := c.Last(); k != nil && (limit <= 0 || len(records) < limit); k, v = c.Prev() {
+			record := &AuditRecord{}
+			if err := json.Unmarshal(v, record); err != nil {
+				return err
+			}
+
+			if record.GuildID == guildID {
+				records = append(records, record)
+			}
+		}
+
+		return nil
+	})
+
+	return records, err
+}
+
+func (s *Bolt) PruneAudit(_ context.Context, before time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketAudit)
+
+		// Records are stored in the order they were added, so stop at the first
+		// one which should be kept.
+		var expired [][]byte
+
+		c := bucket.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			record := &AuditRecord{}
+			if err := json.Unmarshal(v, record); err != nil {
+				return err
+			}
+
+			if !record.Time.Before(before) {
+				break
+			}
+
+			expired = append(expired, k)
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *Bolt) GuildSettings(_ context.Context, guildID disgord.Snowflake) (*GuildSettings, error) {
+	settings := &GuildSettings{}
+	return settings, s.get(bucketGuilds, []byte(guildID.String()), settings)
+}
+
+func (s *Bolt) SetGuildSettings(_ context.Context, settings *GuildSettings) error {
+	return s.put(bucketGuilds, []byte(settings.GuildID.String()), settings)
+}
+
+func (s *Bolt) ChannelSettings(_ context.Context, channelID disgord.Snowflake) (*ChannelSettings, error) {
+	settings := &ChannelSettings{}
+	return settings, s.get(bucketChannels, []byte(channelID.String()), settings)
+}
+
+func (s *Bolt) SetChannelSettings(_ context.Context, settings *ChannelSettings) error {
+	return s.put(bucketChannels, []byte(settings.ChannelID.String()), settings)
+}
+
+func sequenceKey(id uint64) []byte {
+	key := make([]byte, 8) //nolint:gomnd
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
@@ -0,0 +1,285 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	bolt "go.etcd.io/bbolt"
+)
+
+func testBolt(t *testing.T) (s *Bolt, path string) {
+	t.Helper()
+
+	path = filepath.Join(t.TempDir(), "test.db")
+
+	s, err := NewBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s, path
+}
+
+func schemaVersion(t *testing.T, s *Bolt) (version int) {
+	t.Helper()
+
+	err := s.db.View(func(tx *bolt.Tx) (err error) {
+		version, err = strconv.Atoi(string(tx.Bucket(bucketMeta).Get(keyVersion)))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return version
+}
+
+func TestBoltMigrate(t *testing.T) {
+	s, path := testBolt(t)
+
+	if got := schemaVersion(t, s); got != len(boltMigrations) {
+		t.Fatalf("got schema version %d, want %d", got, len(boltMigrations))
+	}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketSilences, bucketGroups, bucketAudit, bucketGuilds, bucketChannels} {
+			if tx.Bucket(name) == nil {
+				return errors.New("missing bucket " + string(name))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err = s.SetSilence(ctx, &Silence{ID: "abc", UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-opening an already migrated store keeps existing data.
+	s, err = NewBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if got := schemaVersion(t, s); got != len(boltMigrations) {
+		t.Fatalf("got schema version %d, want %d", got, len(boltMigrations))
+	}
+
+	if _, err = s.Silence(ctx, "abc"); err != nil {
+		t.Fatalf("expected silence to survive re-opening: %v", err)
+	}
+}
+
+func TestBoltMigratePartial(t *testing.T) {
+	s, path := testBolt(t)
+
+	// Pretend only the first migration was applied.
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucketChannels); err != nil {
+			return err
+		}
+
+		return tx.Bucket(bucketMeta).Put(keyVersion, []byte("1"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = s.Close()
+
+	s, err = NewBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if got := schemaVersion(t, s); got != len(boltMigrations) {
+		t.Fatalf("got schema version %d, want %d", got, len(boltMigrations))
+	}
+
+	if err = s.SetChannelSettings(context.Background(), &ChannelSettings{ChannelID: 1, Instance: "prod"}); err != nil {
+		t.Fatalf("expected pending migration to be applied: %v", err)
+	}
+}
+
+func TestBoltMigrateNewerVersion(t *testing.T) {
+	s, path := testBolt(t)
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketMeta).Put(keyVersion, []byte(strconv.Itoa(len(boltMigrations)+1)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = s.Close()
+
+	if s, err = NewBolt(path); err == nil {
+		_ = s.Close()
+		t.Fatal("expected error when opening a store with a newer schema version")
+	}
+}
+
+func TestBoltNotFound(t *testing.T) {
+	s, _ := testBolt(t)
+	ctx := context.Background()
+
+	if _, err := s.Silence(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+
+	if _, err := s.AlertGroup(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+
+	if _, err := s.GuildSettings(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestBoltPruneAlertGroups(t *testing.T) {
+	s, _ := testBolt(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	groups := map[string]time.Duration{
+		"old-1":  -48 * time.Hour,
+		"old-2":  -25 * time.Hour,
+		"recent": -time.Hour,
+		"new":    0,
+	}
+
+	for key, age := range groups {
+		if err := s.SetAlertGroup(ctx, &AlertGroup{Key: key, ChannelID: 1, MessageID: 2, Updated: now.Add(age)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.PruneAlertGroups(ctx, now.Add(-24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, age := range groups {
+		_, err := s.AlertGroup(ctx, key)
+
+		switch {
+		case age < -24*time.Hour && !errors.Is(err, ErrNotFound):
+			t.Errorf("%s: expected group to be pruned, got %v", key, err)
+		case age >= -24*time.Hour && err != nil:
+			t.Errorf("%s: expected group to be kept, got %v", key, err)
+		}
+	}
+}
+
+func TestBoltAuditHistory(t *testing.T) {
+	s, _ := testBolt(t)
+	ctx := context.Background()
+
+	var guild disgord.Snowflake = 1
+	var other disgord.Snowflake = 2
+
+	for i := 0; i < 5; i++ {
+		for _, g := range []disgord.Snowflake{guild, other} {
+			err := s.AddAudit(ctx, &AuditRecord{GuildID: g, SilenceID: strconv.Itoa(i)})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	records, err := s.AuditHistory(ctx, guild, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+
+	// Newest first, only for the requested guild.
+	for i, want := range []string{"4", "3", "2"} {
+		if records[i].GuildID != guild || records[i].SilenceID != want {
+			t.Errorf("record %d: got guild %d silence %q, want guild %d silence %q", i, records[i].GuildID, records[i].SilenceID, guild, want)
+		}
+	}
+
+	if records[0].ID <= records[1].ID {
+		t.Errorf("expected descending ids, got %d then %d", records[0].ID, records[1].ID)
+	}
+
+	records, err = s.AuditHistory(ctx, guild, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 5 {
+		t.Fatalf("got %d records without a limit, want 5", len(records))
+	}
+
+	if records, err = s.AuditHistory(ctx, 3, 10); err != nil || len(records) != 0 {
+		t.Fatalf("got %d records (err: %v) for unknown guild, want 0", len(records), err)
+	}
+}
+
+func TestBoltPruneAudit(t *testing.T) {
+	s, _ := testBolt(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	// Records are added oldest first, as they would be in practice.
+	for i, age := range []time.Duration{-72 * time.Hour, -48 * time.Hour, -time.Hour, 0} {
+		err := s.AddAudit(ctx, &AuditRecord{GuildID: 1, SilenceID: strconv.Itoa(i), Time: now.Add(age)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.PruneAudit(ctx, now.Add(-24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := s.AuditHistory(ctx, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 || records[0].SilenceID != "3" || records[1].SilenceID != "2" {
+		ids := make([]string, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, record.SilenceID)
+		}
+
+		t.Fatalf("got records %v, want [3 2]", ids)
+	}
+
+	// New records keep getting new IDs after pruning.
+	record := &AuditRecord{GuildID: 1, SilenceID: "4", Time: now}
+	if err = s.AddAudit(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+
+	if record.ID != 5 {
+		t.Fatalf("got id %d, want 5", record.ID)
+	}
+}
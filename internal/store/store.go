@@ -0,0 +1,129 @@
+// Copyright (c) Liam Stanley <me@liamstanley.io>. All rights reserved. Use
+// of this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+// Package store persists metadata the bot manages, which Alertmanager has no
+// knowledge of (e.g. which Discord message shows which silence).
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/lrstanley/discord-alertmanager/internal/models"
+)
+
+// ErrNotFound is returned when the requested entry doesn't exist.
+var ErrNotFound = errors.New("not found")
+
+// Store is implemented by all storage backends.
+type Store interface {
+	// Silence returns the metadata for a silence, or ErrNotFound.
+	Silence(ctx context.Context, id string) (*Silence, error)
+	// Silences returns the metadata for all known silences.
+	Silences(ctx context.Context) ([]*Silence, error)
+	SetSilence(ctx context.Context, silence *Silence) error
+	DeleteSilence(ctx context.Context, id string) error
+
+	// AlertGroup returns the message for an alert group, or ErrNotFound.
+	AlertGroup(ctx context.Context, key string) (*AlertGroup, error)
+	SetAlertGroup(ctx context.Context, group *AlertGroup) error
+	DeleteAlertGroup(ctx context.Context, key string) error
+	// PruneAlertGroups removes all alert groups last updated before the provided
+	// time.
+	PruneAlertGroups(ctx context.Context, before time.Time) error
+
+	// AddAudit records an audit entry, assigning it an ID.
+	AddAudit(ctx context.Context, record *AuditRecord) error
+	// AuditHistory returns up to limit of the most recent audit entries for the
+	// guild, newest first.
+	AuditHistory(ctx context.Context, guildID disgord.Snowflake, limit int) ([]*AuditRecord, error)
+	// PruneAudit removes all audit entries recorded before the provided time.
+	PruneAudit(ctx context.Context, before time.Time) error
+
+	// GuildSettings returns the settings for a guild, or ErrNotFound.
+	GuildSettings(ctx context.Context, guildID disgord.Snowflake) (*GuildSettings, error)
+	SetGuildSettings(ctx context.Context, settings *GuildSettings) error
+
+	// ChannelSettings returns the settings for a channel, or ErrNotFound.
+	ChannelSettings(ctx context.Context, channelID disgord.Snowflake) (*ChannelSettings, error)
+	SetChannelSettings(ctx context.Context, settings *ChannelSettings) error
+
+	Close() error
+}
+
+// Silence is the Discord-specific metadata for a silence created or updated
+// through the bot.
+type Silence struct {
+	ID        string            `json:"id"`
+	GuildID   disgord.Snowflake `json:"guild_id"`
+	ChannelID disgord.Snowflake `json:"channel_id"`
+	MessageID disgord.Snowflake `json:"message_id,omitempty"`
+	UserID    disgord.Snowflake `json:"user_id"`
+
+	// Instance is the name of the Alertmanager instance the silence lives in.
+	Instance string `json:"instance,omitempty"`
+
+	// RemindedFor is the end time of the silence when its creator was last
+	// reminded that it was about to expire.
+	RemindedFor time.Time `json:"reminded_for,omitempty"`
+}
+
+// AlertGroup is the Discord message which was posted for an Alertmanager alert
+// group, through the webhook receiver.
+type AlertGroup struct {
+	Key       string            `json:"key"`
+	ChannelID disgord.Snowflake `json:"channel_id"`
+	MessageID disgord.Snowflake `json:"message_id"`
+	Updated   time.Time         `json:"updated"`
+}
+
+// AuditRecord is a record of a change to a silence.
+type AuditRecord struct {
+	ID                uint64            `json:"id"`
+	Time              time.Time         `json:"time"`
+	GuildID           disgord.Snowflake `json:"guild_id"`
+	UserID            disgord.Snowflake `json:"user_id"`
+	Action            string            `json:"action"`
+	Source            string            `json:"source"`
+	Instance          string            `json:"instance,omitempty"`
+	SilenceID         string            `json:"silence_id"`
+	PreviousSilenceID string            `json:"previous_silence_id,omitempty"`
+	Matchers          []string          `json:"matchers,omitempty"`
+	PreviousMatchers  []string          `json:"previous_matchers,omitempty"`
+	StartsAt          *time.Time        `json:"starts_at,omitempty"`
+	EndsAt            *time.Time        `json:"ends_at,omitempty"`
+	PreviousStartsAt  *time.Time        `json:"previous_starts_at,omitempty"`
+	PreviousEndsAt    *time.Time        `json:"previous_ends_at,omitempty"`
+	Link              string            `json:"link,omitempty"`
+}
+
+// GuildSettings are settings which can be configured per guild.
+type GuildSettings struct {
+	GuildID disgord.Snowflake `json:"guild_id"`
+
+	// AuditChannelID overrides the audit channel configured through flags.
+	AuditChannelID disgord.Snowflake `json:"audit_channel_id,omitempty"`
+}
+
+// ChannelSettings are settings which can be configured per channel.
+type ChannelSettings struct {
+	ChannelID disgord.Snowflake `json:"channel_id"`
+
+	// Instance is the default Alertmanager instance for commands used in the
+	// channel.
+	Instance string `json:"instance,omitempty"`
+}
+
+// New opens the configured storage backend.
+func New(config models.ConfigStore) (Store, error) {
+	switch config.Type {
+	case "bolt", "":
+		return NewBolt(config.Path)
+	default:
+		return nil, fmt.Errorf("unknown store type: %q", config.Type)
+	}
+}
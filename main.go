@@ -12,6 +12,7 @@ import (
 	"github.com/lrstanley/discord-alertmanager/internal/alertmanager"
 	"github.com/lrstanley/discord-alertmanager/internal/bot"
 	"github.com/lrstanley/discord-alertmanager/internal/models"
+	"github.com/lrstanley/discord-alertmanager/internal/store"
 )
 
 var (
@@ -37,12 +38,18 @@ func main() {
 
 	ctx := log.NewContext(context.Background(), logger)
 
-	al, err := alertmanager.NewClient(cli.Flags.Alertmanager, cli.Debug)
+	instances, err := alertmanager.NewInstances(cli.Flags.Alertmanager, cli.Debug)
 	if err != nil {
-		logger.WithError(err).Fatal("error creating alertmanager client")
+		logger.WithError(err).Fatal("error creating alertmanager clients")
 	}
 
-	b, err := bot.New(ctx, cli.Flags.Discord, al, cli.Debug)
+	db, err := store.New(cli.Flags.Store)
+	if err != nil {
+		logger.WithError(err).Fatal("error opening store")
+	}
+	defer db.Close()
+
+	b, err := bot.New(ctx, *cli.Flags, instances, db, cli.Debug)
 	if err != nil {
 		logger.WithError(err).Fatal("error creating bot")
 	}